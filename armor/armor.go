@@ -0,0 +1,511 @@
+// Package armor implements an ASCII armor for shares, suitable for email and
+// tickets.
+//
+// An armored share looks like this:
+//
+//	-----BEGIN SHAMIR SHARE-----
+//	Share-Index: 42
+//	Threshold: 3
+//	Set-ID: 5f3b0c2d8e4a4e1f9d7c6b5a49382716
+//	Field: 11b
+//	Created: 2020-01-02T03:04:05Z
+//	Comment: database master key
+//
+//	hF2kA0qVWyBN...
+//	=njUN
+//	-----END SHAMIR SHARE-----
+//
+// The body holds the y values of the share as produced by shamir.Split or
+// shamir.NewWriter, base64 encoded and followed by a CRC-24 checksum as used
+// by OpenPGP. Only the Share-Index header is required, it holds the x
+// coordinate. The Field header holds the reduction polynomial in hex and is
+// omitted for shamir.DefaultField. Parts and NewReader combine the blocks in
+// that field and refuse blocks whose Set-ID, Threshold or Field headers
+// differ, or fewer blocks than the threshold. The decoder tolerates
+// indentation, trailing whitespace, CRLF line endings, blank lines and
+// re-wrapped lines of the body. A Decoder reads several blocks from the same
+// stream.
+//
+// The y values carry no digest of the secret. To have the reconstructed secret
+// verified, armor the binary encoding of a shamir.Share instead and combine
+// the decoded shares with shamir.CombineShares.
+package armor
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/corvus-ch/shamir"
+)
+
+// ErrChecksum is returned when reading the body of a block if the CRC-24
+// checksum does not match.
+var ErrChecksum = errors.New("armor checksum mismatch")
+
+const (
+	beginLine = "-----BEGIN SHAMIR SHARE-----"
+	endLine   = "-----END SHAMIR SHARE-----"
+	lineLen   = 64
+
+	headerIndex     = "Share-Index"
+	headerThreshold = "Threshold"
+	headerSetID     = "Set-ID"
+	headerField     = "Field"
+	headerCreated   = "Created"
+	headerComment   = "Comment"
+)
+
+// Header holds the headers of an armored share.
+type Header struct {
+	// X is the x coordinate of the share, it must not be zero.
+	X byte
+	// Threshold is the number of shares required, 0 if unknown.
+	Threshold int
+	// SetID identifies the split operation, the zero value is omitted.
+	SetID shamir.SetID
+	// Field is the field the share was computed in, nil means
+	// shamir.DefaultField.
+	Field *shamir.Field
+	// Created is the time the share was created, the zero value is
+	// omitted.
+	Created time.Time
+	// Comment is a free text comment on a single line.
+	Comment string
+}
+
+func (h *Header) write(w io.Writer) error {
+	if h.X == 0 {
+		return fmt.Errorf("x coordinate cannot be zero")
+	}
+	if strings.ContainsAny(h.Comment, "\r\n") {
+		return fmt.Errorf("comment must be a single line")
+	}
+
+	lines := []string{beginLine, fmt.Sprintf("%s: %d", headerIndex, h.X)}
+	if h.Threshold != 0 {
+		lines = append(lines, fmt.Sprintf("%s: %d", headerThreshold, h.Threshold))
+	}
+	if h.SetID != (shamir.SetID{}) {
+		lines = append(lines, fmt.Sprintf("%s: %s", headerSetID, h.SetID))
+	}
+	if nil != h.Field && h.Field.Poly() != shamir.DefaultField.Poly() {
+		lines = append(lines, fmt.Sprintf("%s: %x", headerField, h.Field.Poly()))
+	}
+	if !h.Created.IsZero() {
+		lines = append(lines, fmt.Sprintf("%s: %s", headerCreated, h.Created.UTC().Format(time.RFC3339)))
+	}
+	if "" != h.Comment {
+		lines = append(lines, fmt.Sprintf("%s: %s", headerComment, h.Comment))
+	}
+	lines = append(lines, "", "")
+
+	_, err := io.WriteString(w, strings.Join(lines, "\n"))
+	return err
+}
+
+func (h *Header) set(key, value string) error {
+	var err error
+	switch strings.ToLower(key) {
+	case strings.ToLower(headerIndex):
+		var x uint64
+		if x, err = strconv.ParseUint(value, 10, 8); nil == err && x == 0 {
+			err = fmt.Errorf("x coordinate cannot be zero")
+		}
+		h.X = byte(x)
+	case strings.ToLower(headerThreshold):
+		h.Threshold, err = strconv.Atoi(value)
+	case strings.ToLower(headerSetID):
+		var b []byte
+		if b, err = hex.DecodeString(value); nil == err && len(b) != len(h.SetID) {
+			err = fmt.Errorf("must be %d bytes long", len(h.SetID))
+		}
+		copy(h.SetID[:], b)
+	case strings.ToLower(headerField):
+		var poly uint64
+		if poly, err = strconv.ParseUint(value, 16, 16); nil == err {
+			h.Field, err = field(uint16(poly))
+		}
+	case strings.ToLower(headerCreated):
+		h.Created, err = time.Parse(time.RFC3339, value)
+	case strings.ToLower(headerComment):
+		h.Comment = value
+	}
+	if nil != err {
+		return fmt.Errorf("invalid %s header: %v", key, err)
+	}
+	return nil
+}
+
+// field returns the field with the given reduction polynomial, reusing the
+// predefined ones.
+func field(poly uint16) (*shamir.Field, error) {
+	switch poly {
+	case shamir.DefaultField.Poly():
+		return nil, nil
+	case shamir.AESField.Poly():
+		return shamir.AESField, nil
+	}
+	return shamir.NewField(poly)
+}
+
+// Block is a decoded armored share.
+type Block struct {
+	Header
+	// Body reads the y values of the share. It returns ErrChecksum at the
+	// end if the checksum does not match.
+	Body io.Reader
+}
+
+// Marshal returns the armored share with the given headers and y values.
+func Marshal(h Header, part []byte) ([]byte, error) {
+	var b strings.Builder
+	w, err := Encode(&b, h)
+	if nil != err {
+		return nil, err
+	}
+	if _, err := w.Write(part); nil != err {
+		return nil, err
+	}
+	if err := w.Close(); nil != err {
+		return nil, err
+	}
+	return []byte(b.String()), nil
+}
+
+// Unmarshal decodes an armored share, returning its headers and y values.
+func Unmarshal(data []byte) (Header, []byte, error) {
+	b, err := Decode(strings.NewReader(string(data)))
+	if nil != err {
+		return Header{}, nil, err
+	}
+	part, err := io.ReadAll(b.Body)
+	if nil != err {
+		return Header{}, nil, err
+	}
+	return b.Header, part, nil
+}
+
+// share returns the share with the headers of h and the given y values.
+func (h *Header) share(value []byte) *shamir.Share {
+	return &shamir.Share{
+		X:         h.X,
+		Threshold: h.Threshold,
+		SetID:     h.SetID,
+		Field:     h.Field,
+		Value:     value,
+	}
+}
+
+// Parts decodes armored shares into shares without a tag, ordered by x
+// coordinate, to be combined using shamir.CombineShares. The shares carry the
+// field given by the Field header, so they are combined in the right field.
+//
+// The shares are checked using shamir.CheckShares. It returns a
+// *shamir.SetMismatchError if the Set-ID headers differ and
+// shamir.ErrNotEnoughShares if fewer blocks than the Threshold header are
+// given.
+func Parts(blocks ...[]byte) ([]*shamir.Share, error) {
+	shares := make([]*shamir.Share, 0, len(blocks))
+	for _, data := range blocks {
+		h, part, err := Unmarshal(data)
+		if nil != err {
+			return nil, err
+		}
+		shares = append(shares, h.share(part))
+	}
+	if err := shamir.CheckShares(shares); nil != err {
+		return nil, err
+	}
+	sort.Slice(shares, func(i, j int) bool { return shares[i].X < shares[j].X })
+	return shares, nil
+}
+
+// NewReader decodes the headers of armored shares and returns a reader
+// reconstructing the secret from their bodies, in the field given by the
+// Field header. The headers are checked like Parts does.
+func NewReader(rs ...io.Reader) (io.Reader, error) {
+	readers := make(map[byte]io.Reader, len(rs))
+	shares := make([]*shamir.Share, 0, len(rs))
+	for _, r := range rs {
+		b, err := Decode(r)
+		if nil != err {
+			return nil, err
+		}
+		readers[b.X] = b.Body
+		shares = append(shares, b.share(nil))
+	}
+	if err := shamir.CheckShares(shares); nil != err {
+		return nil, err
+	}
+	f := shares[0].Field
+	if nil == f {
+		f = shamir.DefaultField
+	}
+	return shamir.NewReader(readers, shamir.WithField(f))
+}
+
+// Encode writes the headers to w and returns a writer armoring everything
+// written to it. Close must be called to write the checksum and the end line.
+// It does not close w.
+func Encode(w io.Writer, h Header) (io.WriteCloser, error) {
+	if err := h.write(w); nil != err {
+		return nil, err
+	}
+	e := &encoder{w: w, crc: crc24Init}
+	e.lines = &lineBreaker{w: w}
+	e.b64 = base64.NewEncoder(base64.StdEncoding, e.lines)
+	return e, nil
+}
+
+type encoder struct {
+	w     io.Writer
+	lines *lineBreaker
+	b64   io.WriteCloser
+	crc   uint32
+}
+
+func (e *encoder) Write(p []byte) (int, error) {
+	e.crc = crc24(e.crc, p)
+	return e.b64.Write(p)
+}
+
+func (e *encoder) Close() error {
+	if err := e.b64.Close(); nil != err {
+		return err
+	}
+	if err := e.lines.close(); nil != err {
+		return err
+	}
+	sum := []byte{byte(e.crc >> 16), byte(e.crc >> 8), byte(e.crc)}
+	_, err := fmt.Fprintf(e.w, "=%s\n%s\n", base64.StdEncoding.EncodeToString(sum), endLine)
+	return err
+}
+
+// lineBreaker breaks the base64 output into lines of lineLen characters.
+type lineBreaker struct {
+	w   io.Writer
+	n   int
+	err error
+}
+
+func (l *lineBreaker) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 && nil == l.err {
+		chunk := lineLen - l.n
+		if chunk > len(p) {
+			chunk = len(p)
+		}
+		_, l.err = l.w.Write(p[:chunk])
+		written += chunk
+		l.n += chunk
+		p = p[chunk:]
+		if l.n == lineLen && nil == l.err {
+			_, l.err = l.w.Write([]byte{'\n'})
+			l.n = 0
+		}
+	}
+	return written, l.err
+}
+
+func (l *lineBreaker) close() error {
+	if l.n > 0 && nil == l.err {
+		_, l.err = l.w.Write([]byte{'\n'})
+	}
+	return l.err
+}
+
+// Decode reads the headers of the armored share from r. Any text before the
+// begin line is skipped. The body of the block is read from r on demand.
+//
+// Decode buffers r and may read beyond the end of the block. Use a Decoder to
+// read several armored shares from the same stream.
+func Decode(r io.Reader) (*Block, error) {
+	return NewDecoder(r).Decode()
+}
+
+// Decoder reads consecutive armored shares from a stream.
+type Decoder struct {
+	r *bufio.Reader
+}
+
+// NewDecoder returns a decoder reading from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Decode reads the headers of the next armored share. Any text before the
+// begin line is skipped, including the rest of the body of the previous
+// block. The body of the block is read on demand and can no longer be read
+// once Decode has been called again.
+func (d *Decoder) Decode() (*Block, error) {
+	br := d.r
+	for {
+		line, err := readLine(br)
+		if nil != err {
+			if io.EOF == err {
+				return nil, fmt.Errorf("no armored share found")
+			}
+			return nil, err
+		}
+		if line == beginLine {
+			break
+		}
+	}
+
+	var b Block
+	var body string
+	for {
+		line, err := readLine(br)
+		if nil != err {
+			return nil, unexpected(err)
+		}
+		if line == "" {
+			break
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			// The blank line after the headers got lost.
+			body = line
+			break
+		}
+		if err := b.set(strings.TrimSpace(key), strings.TrimSpace(value)); nil != err {
+			return nil, err
+		}
+	}
+	if b.X == 0 {
+		return nil, fmt.Errorf("missing %s header", headerIndex)
+	}
+
+	b.Body = &decoder{r: br, pending: removeSpace(body), crc: crc24Init}
+	return &b, nil
+}
+
+// decoder reads and decodes the base64 lines of the body.
+type decoder struct {
+	r       *bufio.Reader
+	pending string
+	buf     []byte
+	crc     uint32
+	done    bool
+	err     error
+}
+
+func (d *decoder) Read(p []byte) (int, error) {
+	for len(d.buf) == 0 {
+		if nil != d.err {
+			return 0, d.err
+		}
+		d.fill()
+	}
+	n := copy(p, d.buf)
+	d.buf = d.buf[n:]
+	return n, nil
+}
+
+// fill decodes the next line of the body into buf, or sets err at the end of
+// the body.
+func (d *decoder) fill() {
+	if d.done {
+		d.err = io.EOF
+		return
+	}
+
+	line, err := readLine(d.r)
+	if nil != err {
+		d.err = unexpected(err)
+		return
+	}
+
+	if line == endLine {
+		d.err = fmt.Errorf("missing armor checksum")
+		return
+	}
+	if strings.HasPrefix(line, "=") && len(removeSpace(line)) == 5 {
+		d.done = true
+		if err := d.decode(d.pending); nil != err {
+			d.err = err
+			return
+		}
+		d.pending = ""
+		sum, err := base64.StdEncoding.DecodeString(removeSpace(line)[1:])
+		if nil != err {
+			d.err = fmt.Errorf("invalid armor checksum: %v", err)
+			return
+		}
+		if uint32(sum[0])<<16|uint32(sum[1])<<8|uint32(sum[2]) != d.crc {
+			d.err = ErrChecksum
+			return
+		}
+		if line, err = readLine(d.r); nil != err || line != endLine {
+			d.err = fmt.Errorf("missing end line")
+		}
+		return
+	}
+
+	// Decode complete groups of four characters only, as a line might have
+	// been wrapped at any position.
+	d.pending += removeSpace(line)
+	n := len(d.pending) / 4 * 4
+	if err := d.decode(d.pending[:n]); nil != err {
+		d.err = err
+		return
+	}
+	d.pending = d.pending[n:]
+}
+
+func (d *decoder) decode(s string) error {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if nil != err {
+		return fmt.Errorf("invalid armor body: %v", err)
+	}
+	d.crc = crc24(d.crc, b)
+	d.buf = b
+	return nil
+}
+
+// readLine returns the next line without surrounding whitespace.
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if nil != err && (io.EOF != err || "" == line) {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func unexpected(err error) error {
+	if io.EOF == err {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func removeSpace(s string) string {
+	return strings.Join(strings.Fields(s), "")
+}
+
+const (
+	crc24Init = 0xb704ce
+	crc24Poly = 0x1864cfb
+)
+
+// crc24 updates the CRC-24 checksum of OpenPGP, RFC 4880 section 6.1.
+func crc24(crc uint32, data []byte) uint32 {
+	for _, b := range data {
+		crc ^= uint32(b) << 16
+		for i := 0; i < 8; i++ {
+			crc <<= 1
+			if crc&0x1000000 != 0 {
+				crc ^= crc24Poly
+			}
+		}
+	}
+	return crc & 0xffffff
+}
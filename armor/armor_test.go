@@ -0,0 +1,387 @@
+package armor
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/corvus-ch/shamir"
+)
+
+func TestCrc24(t *testing.T) {
+	// Check value of CRC-24/OPENPGP.
+	if out := crc24(crc24Init, []byte("123456789")); out != 0x21cf02 {
+		t.Fatalf("bad: %x", out)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	h := Header{
+		X:         42,
+		Threshold: 3,
+		SetID:     shamir.SetID{0xab, 0xcd},
+		Field:     shamir.AESField,
+		Created:   time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Comment:   "database master key",
+	}
+	part := bytes.Repeat([]byte("0123456789"), 10)
+
+	data, err := Marshal(h, part)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	lines := strings.Split(string(data), "\n")
+	exp := []string{
+		beginLine,
+		"Share-Index: 42",
+		"Threshold: 3",
+		"Set-ID: abcd0000000000000000000000000000",
+		"Field: 11b",
+		"Created: 2020-01-02T03:04:05Z",
+		"Comment: database master key",
+		"",
+	}
+	for i, l := range exp {
+		if lines[i] != l {
+			t.Fatalf("bad line %d: %q", i, lines[i])
+		}
+	}
+	if len(lines[8]) != lineLen || lines[len(lines)-2] != endLine || !strings.HasPrefix(lines[len(lines)-3], "=") {
+		t.Fatalf("bad: %s", data)
+	}
+
+	out, outPart, err := Unmarshal(data)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != h || !bytes.Equal(outPart, part) {
+		t.Fatalf("bad: %v %q", out, outPart)
+	}
+}
+
+func TestMarshal_invalid(t *testing.T) {
+	if _, err := Marshal(Header{}, []byte("foo")); err == nil {
+		t.Fatalf("expect error")
+	}
+	if _, err := Marshal(Header{X: 1, Comment: "a\nb"}, []byte("foo")); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestUnmarshal_damaged(t *testing.T) {
+	part := bytes.Repeat([]byte("secret"), 20)
+	data, err := Marshal(Header{X: 7, Threshold: 2}, part)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	s := string(data)
+	body := strings.Split(s, "\n")[4:7]
+
+	tests := map[string]string{
+		"crlf":       strings.ReplaceAll(s, "\n", "\r\n"),
+		"indented":   "  " + strings.ReplaceAll(s, "\n", "\n\t  "),
+		"trailing":   strings.ReplaceAll(s, "\n", "  \n"),
+		"quoted":     "Hello,\n\nhere is the share:\n\n" + s + "\nRegards\n",
+		"rewrapped":  strings.Replace(s, strings.Join(body, "\n"), strings.Join(body, "")[:30]+"\n"+strings.Join(body, "")[30:], 1),
+		"spaces":     strings.Replace(s, body[0], body[0][:10]+" "+body[0][10:], 1),
+		"blank":      strings.Replace(s, body[0]+"\n", body[0]+"\n\n", 1),
+		"no blank":   strings.Replace(s, "Threshold: 2\n\n", "Threshold: 2\n", 1),
+		"lower case": strings.Replace(s, "Share-Index", "share-index", 1),
+	}
+	for name, in := range tests {
+		h, out, err := Unmarshal([]byte(in))
+		if err != nil {
+			t.Fatalf("%s: err: %v", name, err)
+		}
+		if h.X != 7 || h.Threshold != 2 || !bytes.Equal(out, part) {
+			t.Fatalf("%s: bad: %v %q", name, h, out)
+		}
+	}
+}
+
+func TestUnmarshal_invalid(t *testing.T) {
+	data, err := Marshal(Header{X: 7}, []byte("secret"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	s := string(data)
+
+	tests := map[string]string{
+		"empty":     "",
+		"no index":  strings.Replace(s, "Share-Index: 7\n", "", 1),
+		"zero":      strings.Replace(s, "Share-Index: 7", "Share-Index: 0", 1),
+		"index":     strings.Replace(s, "Share-Index: 7", "Share-Index: 256", 1),
+		"set id":    strings.Replace(s, "Share-Index: 7", "Share-Index: 7\nSet-ID: abc", 1),
+		"created":   strings.Replace(s, "Share-Index: 7", "Share-Index: 7\nCreated: yesterday", 1),
+		"threshold": strings.Replace(s, "Share-Index: 7", "Share-Index: 7\nThreshold: two", 1),
+		"field":     strings.Replace(s, "Share-Index: 7", "Share-Index: 7\nField: 100", 1),
+		"truncated": s[:len(s)-40],
+		"no crc":    strings.Replace(s, strings.Split(s, "\n")[4]+"\n", "", 1),
+		"no end":    strings.Replace(s, endLine, "", 1),
+		"body":      strings.Replace(s, "c2VjcmV0", "c2VjcmV!", 1),
+	}
+	for name, in := range tests {
+		if _, _, err := Unmarshal([]byte(in)); err == nil {
+			t.Errorf("%s: expect error", name)
+		}
+	}
+
+	if _, _, err := Unmarshal([]byte(strings.Replace(s, "c2VjcmV0", "c2VjcmV1", 1))); err != ErrChecksum {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestParts(t *testing.T) {
+	secret := []byte("my secret")
+	out, err := shamir.Split(secret, 5, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	blocks := make([][]byte, 0, 3)
+	for x, part := range out {
+		data, err := Marshal(Header{X: x, Threshold: 3}, part)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		blocks = append(blocks, data)
+		if len(blocks) == 3 {
+			break
+		}
+	}
+
+	shares, err := Parts(blocks...)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	recomb, err := shamir.CombineShares(shares)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recomb, secret) {
+		t.Fatalf("bad: %q", recomb)
+	}
+
+	if _, err := Parts(blocks[0], blocks[0]); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestParts_field(t *testing.T) {
+	secret := []byte("my secret")
+	out, err := shamir.Split(secret, 3, 2, shamir.WithField(shamir.AESField), shamir.WithCoordinates(1, 2, 3))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	blocks := make([][]byte, 0, 2)
+	rs := make([]io.Reader, 0, 2)
+	for _, x := range []byte{1, 3} {
+		data, err := Marshal(Header{X: x, Threshold: 2, Field: shamir.AESField}, out[x])
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		blocks = append(blocks, data)
+		rs = append(rs, bytes.NewReader(data))
+	}
+
+	shares, err := Parts(blocks...)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if shares[0].X != 1 || shares[0].Field != shamir.AESField {
+		t.Fatalf("bad: %v", shares[0])
+	}
+	recomb, err := shamir.CombineShares(shares)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recomb, secret) {
+		t.Fatalf("bad: %q", recomb)
+	}
+
+	r, err := NewReader(rs...)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if recomb, err = io.ReadAll(r); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recomb, secret) {
+		t.Fatalf("bad: %q", recomb)
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	var stream bytes.Buffer
+	for x := byte(1); x <= 3; x++ {
+		data, err := Marshal(Header{X: x}, bytes.Repeat([]byte{x}, 100))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		stream.WriteString("text between the blocks\n")
+		stream.Write(data)
+	}
+
+	d := NewDecoder(&stream)
+	for x := byte(1); x <= 3; x++ {
+		b, err := d.Decode()
+		if err != nil {
+			t.Fatalf("%d: err: %v", x, err)
+		}
+		if b.X != x {
+			t.Fatalf("bad: %v", b.Header)
+		}
+		// The body of the second block is left unread.
+		if x == 2 {
+			continue
+		}
+		body, err := io.ReadAll(b.Body)
+		if err != nil {
+			t.Fatalf("%d: err: %v", x, err)
+		}
+		if !bytes.Equal(body, bytes.Repeat([]byte{x}, 100)) {
+			t.Fatalf("%d: bad: %v", x, body)
+		}
+	}
+	if _, err := d.Decode(); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	secret := bytes.Repeat([]byte("streamed secret "), 1000)
+
+	buffers := make(map[byte]*bytes.Buffer)
+	closers := make([]io.Closer, 0, 3)
+	w, err := shamir.NewWriter(3, 2, func(x byte) (io.Writer, error) {
+		buffers[x] = &bytes.Buffer{}
+		e, err := Encode(buffers[x], Header{X: x, Threshold: 2})
+		closers = append(closers, e)
+		return e, err
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := w.Write(secret); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, c := range closers {
+		if err := c.Close(); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	rs := make([]io.Reader, 0, 2)
+	for _, buf := range buffers {
+		rs = append(rs, buf)
+	}
+	r, err := NewReader(rs[:2]...)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	recomb, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recomb, secret) {
+		t.Fatalf("bad: %q", recomb)
+	}
+}
+
+func TestParts_mismatch(t *testing.T) {
+	marshal := func(h Header) []byte {
+		data, err := Marshal(h, []byte("foo"))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return data
+	}
+	a := shamir.SetID{1}
+	b := shamir.SetID{2}
+
+	// Parts and NewReader must agree.
+	check := func(name string, blocks [][]byte, fn func(error) bool) {
+		if _, err := Parts(blocks...); !fn(err) {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+		rs := make([]io.Reader, len(blocks))
+		for i, data := range blocks {
+			rs[i] = bytes.NewReader(data)
+		}
+		if _, err := NewReader(rs...); !fn(err) {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+	}
+
+	check("set id", [][]byte{
+		marshal(Header{X: 1, Threshold: 2, SetID: a}),
+		marshal(Header{X: 2, Threshold: 2, SetID: b}),
+		marshal(Header{X: 3, Threshold: 2, SetID: a}),
+	}, func(err error) bool {
+		e, ok := err.(*shamir.SetMismatchError)
+		return ok && e.SetID == a && bytes.Equal(e.X, []byte{2})
+	})
+	check("not enough", [][]byte{
+		marshal(Header{X: 1, Threshold: 3, SetID: a}),
+		marshal(Header{X: 2, Threshold: 3, SetID: a}),
+	}, func(err error) bool { return err == shamir.ErrNotEnoughShares })
+	check("none", nil, func(err error) bool { return err == shamir.ErrNotEnoughShares })
+	check("threshold", [][]byte{
+		marshal(Header{X: 1, Threshold: 2, SetID: a}),
+		marshal(Header{X: 2, Threshold: 3, SetID: a}),
+		marshal(Header{X: 3, Threshold: 3, SetID: a}),
+	}, func(err error) bool { return err != nil })
+	check("field", [][]byte{
+		marshal(Header{X: 1, Threshold: 2, SetID: a}),
+		marshal(Header{X: 2, Threshold: 2, SetID: a, Field: shamir.AESField}),
+	}, func(err error) bool { return err != nil })
+	check("valid", [][]byte{
+		marshal(Header{X: 1, Threshold: 2, SetID: a, Field: shamir.AESField}),
+		marshal(Header{X: 2, Threshold: 2, SetID: a, Field: shamir.AESField}),
+	}, func(err error) bool { return err == nil })
+}
+
+func TestMarshal_share(t *testing.T) {
+	secret := []byte("my secret")
+	shares, err := shamir.SplitShares(secret, 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	decoded := make([]*shamir.Share, 0, 2)
+	for _, s := range shares[:2] {
+		data, err := s.MarshalBinary()
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		block, err := Marshal(Header{X: s.X, Threshold: s.Threshold, SetID: s.SetID}, data)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		_, data, err = Unmarshal(block)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		var out shamir.Share
+		if err := out.UnmarshalBinary(data); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		decoded = append(decoded, &out)
+	}
+
+	decoded[1].Value[0] ^= 1
+	if _, err := shamir.CombineShares(decoded); err != shamir.ErrVerification {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	decoded[1].Value[0] ^= 1
+	recomb, err := shamir.CombineShares(decoded)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recomb, secret) {
+		t.Fatalf("bad: %q", recomb)
+	}
+}
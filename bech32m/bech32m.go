@@ -0,0 +1,247 @@
+// Package bech32m encodes shares as text using bech32m as specified by
+// BIP-0350.
+//
+// The data part holds the binary encoding of the share, including its x
+// coordinate, threshold and share set. Unlike addresses, the encoding is not
+// limited to 90 characters. The checksum detects any single mistyped
+// character regardless of the length and up to four errors in strings of up
+// to 89 characters. Longer strings have a slightly weaker guarantee for
+// multiple errors.
+package bech32m
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/corvus-ch/shamir"
+)
+
+// DefaultHRP is the human-readable prefix suggested for shares.
+const DefaultHRP = "gfss"
+
+const (
+	charset      = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	checksumLen  = 6
+	bech32mConst = 0x2bc830a3
+	maxHRPLen    = 83
+)
+
+var generator = [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+// ChecksumError is returned by Decode if the checksum does not match.
+type ChecksumError struct {
+	// Pos is the position of the likely mistyped character in the string,
+	// or -1 if the error cannot be attributed to a single character. Errors
+	// are reliably located in data parts of up to 1023 characters only, as
+	// the checksum repeats with a period of 1023 characters.
+	Pos int
+}
+
+func (e *ChecksumError) Error() string {
+	if e.Pos < 0 {
+		return "invalid checksum"
+	}
+	return fmt.Sprintf("invalid checksum, likely error at position %d", e.Pos)
+}
+
+// Encode returns the share encoded with the given human-readable prefix.
+func Encode(hrp string, s *shamir.Share) (string, error) {
+	if err := checkHRP(hrp); nil != err {
+		return "", err
+	}
+	hrp = strings.ToLower(hrp)
+
+	data, err := s.MarshalBinary()
+	if nil != err {
+		return "", err
+	}
+
+	values := convertBits(data, 8, 5, true)
+	sum := polymod(append(append(expandHRP(hrp), values...), make([]byte, checksumLen)...)) ^ bech32mConst
+
+	var b strings.Builder
+	b.Grow(len(hrp) + 1 + len(values) + checksumLen)
+	b.WriteString(hrp)
+	b.WriteByte('1')
+	for _, v := range values {
+		b.WriteByte(charset[v])
+	}
+	for i := 0; i < checksumLen; i++ {
+		b.WriteByte(charset[sum>>uint(5*(checksumLen-1-i))&31])
+	}
+	return b.String(), nil
+}
+
+// Decode parses a share encoded by Encode and returns its human-readable
+// prefix. A checksum mismatch is reported as *ChecksumError.
+func Decode(str string) (string, *shamir.Share, error) {
+	hrp, values, err := decode(str)
+	if nil != err {
+		return "", nil, err
+	}
+
+	data, ok := convertBitsStrict(values)
+	if !ok {
+		return "", nil, fmt.Errorf("invalid padding")
+	}
+
+	var s shamir.Share
+	if err := s.UnmarshalBinary(data); nil != err {
+		return "", nil, err
+	}
+	return hrp, &s, nil
+}
+
+// decode splits a bech32m string into its human-readable prefix and the
+// values of its data part after verifying the checksum.
+func decode(str string) (string, []byte, error) {
+	if strings.ToLower(str) != str && strings.ToUpper(str) != str {
+		return "", nil, fmt.Errorf("mixed case")
+	}
+	str = strings.ToLower(str)
+
+	sep := strings.LastIndexByte(str, '1')
+	if sep < 1 || sep+1+checksumLen > len(str) {
+		return "", nil, fmt.Errorf("missing separator or checksum")
+	}
+	hrp := str[:sep]
+	if err := checkHRP(hrp); nil != err {
+		return "", nil, err
+	}
+
+	values := make([]byte, len(str)-sep-1)
+	for i := range values {
+		v := strings.IndexByte(charset, str[sep+1+i])
+		if v < 0 {
+			return "", nil, fmt.Errorf("invalid character %q at position %d", str[sep+1+i], sep+1+i)
+		}
+		values[i] = byte(v)
+	}
+
+	if residue := polymod(append(expandHRP(hrp), values...)) ^ bech32mConst; residue != 0 {
+		pos := locate(residue, len(values))
+		if pos >= 0 {
+			pos += sep + 1
+		}
+		return "", nil, &ChecksumError{Pos: pos}
+	}
+
+	return hrp, values[:len(values)-checksumLen], nil
+}
+
+func checkHRP(hrp string) error {
+	if len(hrp) < 1 || len(hrp) > maxHRPLen {
+		return fmt.Errorf("human-readable prefix must be 1 to %d characters long", maxHRPLen)
+	}
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return fmt.Errorf("invalid character in human-readable prefix")
+		}
+	}
+	if strings.ToLower(hrp) != hrp && strings.ToUpper(hrp) != hrp {
+		return fmt.Errorf("mixed case")
+	}
+	return nil
+}
+
+func expandHRP(hrp string) []byte {
+	out := make([]byte, 0, 2*len(hrp)+1)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]>>5)
+	}
+	out = append(out, 0)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]&31)
+	}
+	return out
+}
+
+func polymod(values []byte) uint32 {
+	chk := uint32(1)
+	for _, v := range values {
+		chk = step(chk, v)
+	}
+	return chk
+}
+
+func step(chk uint32, v byte) uint32 {
+	b := chk >> 25
+	chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+	for i := 0; i < 5; i++ {
+		if b>>uint(i)&1 == 1 {
+			chk ^= generator[i]
+		}
+	}
+	return chk
+}
+
+// locate returns the position within the data part of the single character
+// error explaining the residue of the checksum, or -1 if there is no such
+// error or more than one.
+//
+// The checksum is linear, so an error e at distance d from the end changes
+// the residue by the residue of e followed by d zeros, which is computed once
+// per bit of e by feeding zeros.
+func locate(residue uint32, n int) int {
+	var syndromes [5][]uint32
+	for bit := range syndromes {
+		syndromes[bit] = make([]uint32, n)
+		chk := uint32(1) << uint(bit)
+		for d := 0; d < n; d++ {
+			syndromes[bit][d] = chk
+			chk = step(chk, 0)
+		}
+	}
+
+	pos := -1
+	for d := 0; d < n; d++ {
+		for e := 1; e < 32; e++ {
+			var s uint32
+			for bit := range syndromes {
+				if e>>uint(bit)&1 == 1 {
+					s ^= syndromes[bit][d]
+				}
+			}
+			if s == residue {
+				if pos >= 0 {
+					return -1
+				}
+				pos = n - 1 - d
+			}
+		}
+	}
+	return pos
+}
+
+// convertBits regroups data of frombits wide values into tobits wide values.
+func convertBits(data []byte, frombits, tobits uint, pad bool) []byte {
+	var acc uint32
+	var bits uint
+	maxv := uint32(1)<<tobits - 1
+	out := make([]byte, 0, len(data)*int(frombits)/int(tobits)+1)
+	for _, v := range data {
+		acc = acc<<frombits | uint32(v)
+		bits += frombits
+		for bits >= tobits {
+			bits -= tobits
+			out = append(out, byte(acc>>bits&maxv))
+		}
+	}
+	if pad && bits > 0 {
+		out = append(out, byte(acc<<(tobits-bits)&maxv))
+	}
+	return out
+}
+
+// convertBitsStrict regroups 5 bit values into bytes, requiring the padding
+// to be shorter than 5 bits and zero.
+func convertBitsStrict(values []byte) ([]byte, bool) {
+	bits := 5 * len(values) % 8
+	if bits >= 5 {
+		return nil, false
+	}
+	if len(values) > 0 && values[len(values)-1]&(1<<uint(bits)-1) != 0 {
+		return nil, false
+	}
+	return convertBits(values, 5, 8, false), true
+}
@@ -0,0 +1,150 @@
+package bech32m
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/corvus-ch/shamir"
+)
+
+func TestDecode_vectors(t *testing.T) {
+	// Valid bech32m strings of BIP-0350.
+	valid := []string{
+		"A1LQFN3A",
+		"a1lqfn3a",
+		"an83characterlonghumanreadablepartthatcontainsthetheexcludedcharactersbioandnumber11sg7hg6",
+		"abcdef1l7aum6echk45nj3s0wdvt2fg8x9yrzpqzd3ryx",
+		"11llllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllludsr8",
+		"split1checkupstagehandshakeupstreamerranterredcaperredlc445v",
+		"?1v759aa",
+	}
+	for _, str := range valid {
+		if _, _, err := decode(str); err != nil {
+			t.Errorf("%s: err: %v", str, err)
+		}
+	}
+
+	// Invalid bech32m strings of BIP-0350.
+	invalid := []string{
+		"\x201xj0phk",
+		"\x7f1g6xzxy",
+		"qyrz8wqd2c9m",
+		"1qyrz8wqd2c9m",
+		"y1b0jsk6g",
+		"lt1igcx5c0",
+		"in1muywd",
+		"mm1crxm3i",
+		"au1s5cgom",
+		"M1VUXWEZ",
+		"16plkw9",
+		"1p2gdwpf",
+		// Valid bech32, but not bech32m.
+		"a12uel5l",
+	}
+	for _, str := range invalid {
+		if _, _, err := decode(str); err == nil {
+			t.Errorf("%q: expect error", str)
+		}
+	}
+}
+
+func testShare(t *testing.T, n int) *shamir.Share {
+	shares, err := shamir.SplitShares(bytes.Repeat([]byte("secret"), n), 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return shares[0]
+}
+
+func TestEncode(t *testing.T) {
+	for _, n := range []int{1, 100, 500} {
+		s := testShare(t, n)
+
+		str, err := Encode(DefaultHRP, s)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !strings.HasPrefix(str, DefaultHRP+"1") {
+			t.Fatalf("bad: %s", str)
+		}
+
+		for _, in := range []string{str, strings.ToUpper(str)} {
+			hrp, out, err := Decode(in)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if hrp != DefaultHRP || out.X != s.X || out.SetID != s.SetID || !bytes.Equal(out.Value, s.Value) || !bytes.Equal(out.Tag, s.Tag) {
+				t.Fatalf("bad: %v", out)
+			}
+		}
+	}
+}
+
+func TestEncode_hrp(t *testing.T) {
+	s := testShare(t, 1)
+
+	str, err := Encode("VAULT", s)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if hrp, _, err := Decode(str); err != nil || hrp != "vault" {
+		t.Fatalf("bad: %s %v", hrp, err)
+	}
+
+	for _, hrp := range []string{"", "a b", "MiXed", strings.Repeat("a", 84)} {
+		if _, err := Encode(hrp, s); err == nil {
+			t.Errorf("%q: expect error", hrp)
+		}
+	}
+}
+
+func TestDecode_locate(t *testing.T) {
+	for _, n := range []int{1, 50, 500} {
+		str, err := Encode(DefaultHRP, testShare(t, n))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		for _, pos := range []int{len(DefaultHRP) + 1, len(str) / 2, len(str) - 1} {
+			b := []byte(str)
+			b[pos] = charset[(strings.IndexByte(charset, b[pos])+7)%32]
+
+			_, _, err := Decode(string(b))
+			cerr, ok := err.(*ChecksumError)
+			if !ok {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			// Beyond 1023 characters, errors cannot always be located.
+			if len(str) > 1023 {
+				continue
+			}
+			if cerr.Pos != pos {
+				t.Fatalf("%d: bad position: %d", pos, cerr.Pos)
+			}
+		}
+	}
+}
+
+func TestDecode_invalid(t *testing.T) {
+	str, err := Encode(DefaultHRP, testShare(t, 1))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	tests := map[string]string{
+		"mixed case": "G" + str[1:],
+		"separator":  strings.Replace(str, "1", "", 1),
+		"character":  str[:10] + "b" + str[11:],
+		"swapped":    str[:10] + str[11:12] + str[10:11] + str[12:],
+	}
+	for name, in := range tests {
+		if _, _, err := Decode(in); err == nil {
+			t.Errorf("%s: expect error", name)
+		}
+	}
+
+	if (&ChecksumError{Pos: -1}).Error() != "invalid checksum" {
+		t.Fatalf("bad message")
+	}
+}
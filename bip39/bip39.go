@@ -0,0 +1,216 @@
+// Package bip39 splits BIP-0039 entropy into shares presented as mnemonics.
+//
+// Each share is encoded like a BIP-0039 mnemonic, preceded by one word
+// encoding the x coordinate of the share. The checksum covers the x coordinate
+// as well as the y values, so a mistyped word is detected. Operators can
+// transcribe and type words instead of hex, and the entropy is recovered
+// directly from the mnemonics.
+//
+// The shares are not valid BIP-0039 mnemonics themselves and cannot be
+// imported into a wallet. Use EntropyToMnemonic on the combined entropy to
+// obtain the original mnemonic.
+package bip39
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/corvus-ch/shamir"
+)
+
+// ErrInvalidChecksum is returned if the checksum of a mnemonic does not
+// match, which indicates a mistyped word.
+var ErrInvalidChecksum = errors.New("invalid mnemonic checksum")
+
+const radixBits = 11
+
+// wordIndex maps the first four letters of every word to its index.
+var wordIndex = func() map[string]int {
+	m := make(map[string]int, len(wordlist))
+	for i, w := range wordlist {
+		if len(w) < 4 {
+			m[w] = i
+			continue
+		}
+		m[w[:4]] = i
+	}
+	return m
+}()
+
+// Split takes BIP-0039 entropy of 16, 20, 24, 28 or 32 bytes and generates a
+// `parts` number of shares as mnemonics, `threshold` of which are required to
+// reconstruct the entropy. The options are passed on to shamir.Split and must
+// be passed to Combine as well.
+func Split(entropy []byte, parts, threshold int, opts ...shamir.Option) ([]string, error) {
+	if err := checkEntropy(entropy); nil != err {
+		return nil, err
+	}
+
+	out, err := shamir.Split(entropy, parts, threshold, opts...)
+	if nil != err {
+		return nil, err
+	}
+
+	shares := make([]string, 0, len(out))
+	for x := 1; x < 256; x++ {
+		if y, ok := out[byte(x)]; ok {
+			shares = append(shares, FormatShare(byte(x), y))
+		}
+	}
+
+	return shares, nil
+}
+
+// Combine reconstructs the entropy from the mnemonics of at least `threshold`
+// shares.
+func Combine(shares []string, opts ...shamir.Option) ([]byte, error) {
+	parts := make(map[byte][]byte, len(shares))
+	for _, s := range shares {
+		x, y, err := ParseShare(s)
+		if nil != err {
+			return nil, err
+		}
+		if _, ok := parts[x]; ok {
+			return nil, fmt.Errorf("duplicate share %d", x)
+		}
+		parts[x] = y
+	}
+
+	return shamir.Combine(parts, opts...)
+}
+
+// FormatShare returns the mnemonic of the share with x coordinate x and y
+// values y.
+func FormatShare(x byte, y []byte) string {
+	return wordlist[x] + " " + encode(y, checksum(y, x))
+}
+
+// ParseShare parses the mnemonic of a share, returning its x coordinate and y
+// values.
+func ParseShare(mnemonic string) (byte, []byte, error) {
+	idx, err := indexes(mnemonic)
+	if nil != err {
+		return 0, nil, err
+	}
+	if len(idx) < 1 || idx[0] < 1 || idx[0] > 255 {
+		return 0, nil, fmt.Errorf("invalid share index")
+	}
+	x := byte(idx[0])
+
+	y, err := decode(idx[1:], func(y []byte) []byte { return checksum(y, x) })
+	if nil != err {
+		return 0, nil, err
+	}
+
+	return x, y, nil
+}
+
+// EntropyToMnemonic returns the BIP-0039 mnemonic of the entropy.
+func EntropyToMnemonic(entropy []byte) (string, error) {
+	if err := checkEntropy(entropy); nil != err {
+		return "", err
+	}
+	return encode(entropy, checksum(entropy)), nil
+}
+
+// MnemonicToEntropy returns the entropy of the BIP-0039 mnemonic.
+func MnemonicToEntropy(mnemonic string) ([]byte, error) {
+	idx, err := indexes(mnemonic)
+	if nil != err {
+		return nil, err
+	}
+	return decode(idx, func(e []byte) []byte { return checksum(e) })
+}
+
+func checkEntropy(entropy []byte) error {
+	if len(entropy) < 16 || len(entropy) > 32 || len(entropy)%4 != 0 {
+		return fmt.Errorf("entropy must be 16, 20, 24, 28 or 32 bytes long")
+	}
+	return nil
+}
+
+// checksum returns the SHA-256 digest of the data and the suffix, the first
+// len(data)/4 bits of which form the checksum.
+func checksum(data []byte, suffix ...byte) []byte {
+	h := sha256.New()
+	h.Write(data)
+	h.Write(suffix)
+	return h.Sum(nil)
+}
+
+// encode converts data followed by the checksum bits into words.
+func encode(data, sum []byte) string {
+	csBits := len(data) / 4
+	bits := append(append([]byte(nil), data...), sum[0])
+
+	words := make([]string, (8*len(data)+csBits)/radixBits)
+	acc, n, i := 0, 0, 0
+	for _, b := range bits {
+		acc = acc<<8 | int(b)
+		n += 8
+		for n >= radixBits && i < len(words) {
+			n -= radixBits
+			words[i] = wordlist[acc>>n&0x7ff]
+			acc &= 1<<n - 1
+			i++
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// decode converts the word indexes back into data and verifies the checksum
+// computed by sum.
+func decode(idx []int, sum func([]byte) []byte) ([]byte, error) {
+	total := radixBits * len(idx)
+	if total%33 != 0 || total < 132 || total > 264 {
+		return nil, fmt.Errorf("mnemonic must be 12, 15, 18, 21 or 24 words long")
+	}
+	csBits := total / 33
+
+	data := make([]byte, 0, (total-csBits)/8+1)
+	acc, n := 0, 0
+	for _, v := range idx {
+		acc = acc<<radixBits | v
+		n += radixBits
+		for n >= 8 {
+			n -= 8
+			data = append(data, byte(acc>>n))
+		}
+		acc &= 1<<n - 1
+	}
+	// The checksum bits follow the data, partly as full byte and partly as
+	// remaining bits.
+	entLen := (total - csBits) / 8
+	cs := acc
+	for _, b := range data[entLen:] {
+		cs |= int(b) << n
+	}
+	data = data[:entLen]
+
+	if cs != int(sum(data)[0])>>(8-csBits) {
+		return nil, ErrInvalidChecksum
+	}
+	return data, nil
+}
+
+// indexes looks up the words of the mnemonic. Words are matched case
+// insensitive by their first four letters, which must be followed by the
+// remaining letters of the word, if any.
+func indexes(mnemonic string) ([]int, error) {
+	words := strings.Fields(strings.ToLower(mnemonic))
+	idx := make([]int, len(words))
+	for i, w := range words {
+		key := w
+		if len(key) > 4 {
+			key = key[:4]
+		}
+		j, ok := wordIndex[key]
+		if !ok || !strings.HasPrefix(wordlist[j], w) {
+			return nil, fmt.Errorf("unknown word %q", w)
+		}
+		idx[i] = j
+	}
+	return idx, nil
+}
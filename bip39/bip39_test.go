@@ -0,0 +1,166 @@
+package bip39
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/corvus-ch/shamir"
+)
+
+func TestWordlist(t *testing.T) {
+	// The SHA-256 digest of english.txt as published with BIP-0039.
+	h := sha256.Sum256([]byte(strings.Join(wordlist[:], "\n") + "\n"))
+	if hex.EncodeToString(h[:]) != "2f5eed53a4727b4bf8880d8f3f199efc90e58503646d9ff8eff3a2ed3b24dbda" {
+		t.Fatalf("bad wordlist")
+	}
+	if len(wordIndex) != len(wordlist) {
+		t.Fatalf("prefixes not unique")
+	}
+}
+
+// Test vectors of BIP-0039.
+var vectors = []struct {
+	entropy  string
+	mnemonic string
+}{
+	{"00000000000000000000000000000000", "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"},
+	{"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f", "legal winner thank year wave sausage worth useful legal winner thank yellow"},
+	{"80808080808080808080808080808080", "letter advice cage absurd amount doctor acoustic avoid letter advice cage above"},
+	{"ffffffffffffffffffffffffffffffff", "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong"},
+	{"0000000000000000000000000000000000000000000000000000000000000000", "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon art"},
+}
+
+func TestEntropyToMnemonic(t *testing.T) {
+	for _, v := range vectors {
+		entropy, _ := hex.DecodeString(v.entropy)
+		m, err := EntropyToMnemonic(entropy)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if m != v.mnemonic {
+			t.Fatalf("bad: %s", m)
+		}
+
+		out, err := MnemonicToEntropy(v.mnemonic)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !bytes.Equal(out, entropy) {
+			t.Fatalf("bad: %x", out)
+		}
+	}
+
+	if _, err := EntropyToMnemonic(make([]byte, 17)); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestMnemonicToEntropy_invalid(t *testing.T) {
+	tests := map[string]string{
+		"short":   "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+		"unknown": "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon aboutx",
+		"prefix":  "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon ab",
+	}
+	for name, m := range tests {
+		if _, err := MnemonicToEntropy(m); err == nil {
+			t.Errorf("%s: expect error", name)
+		}
+	}
+
+	m := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon"
+	if _, err := MnemonicToEntropy(m); err != ErrInvalidChecksum {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out, err := MnemonicToEntropy("ZOO zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo WRON")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(out, bytes.Repeat([]byte{0xff}, 16)) {
+		t.Fatalf("bad: %x", out)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	for _, n := range []int{16, 20, 24, 28, 32} {
+		entropy := bytes.Repeat([]byte{0xa5}, n)
+
+		shares, err := Split(entropy, 5, 3)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if len(shares) != 5 {
+			t.Fatalf("bad: %v", shares)
+		}
+		for _, s := range shares {
+			if len(strings.Fields(s)) != 1+3*n/4 {
+				t.Fatalf("bad: %s", s)
+			}
+		}
+
+		out, err := Combine(shares[1:4])
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !bytes.Equal(out, entropy) {
+			t.Fatalf("bad: %x", out)
+		}
+	}
+}
+
+func TestSplit_invalid(t *testing.T) {
+	for _, n := range []int{0, 12, 18, 36} {
+		if _, err := Split(make([]byte, n), 3, 2); err == nil {
+			t.Errorf("%d: expect error", n)
+		}
+	}
+}
+
+func TestParseShare(t *testing.T) {
+	m := FormatShare(42, bytes.Repeat([]byte{0x5a}, 16))
+
+	x, y, err := ParseShare(m)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if x != 42 || !bytes.Equal(y, bytes.Repeat([]byte{0x5a}, 16)) {
+		t.Fatalf("bad: %d %x", x, y)
+	}
+
+	// The checksum covers the share index.
+	words := strings.Fields(m)
+	words[0] = wordlist[43]
+	if _, _, err := ParseShare(strings.Join(words, " ")); err != ErrInvalidChecksum {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	words[0] = wordlist[0]
+	if _, _, err := ParseShare(strings.Join(words, " ")); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestCombine_invalid(t *testing.T) {
+	shares, err := Split(make([]byte, 16), 3, 2, shamir.WithCoordinates(1, 2, 3))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	tests := map[string][]string{
+		"duplicate": {shares[0], shares[0]},
+		"one":       {shares[0]},
+		"invalid":   {shares[0], "foo"},
+	}
+	for name, s := range tests {
+		if _, err := Combine(s); err == nil {
+			t.Errorf("%s: expect error", name)
+		}
+	}
+
+	if !strings.HasPrefix(shares[0], wordlist[1]+" ") {
+		t.Fatalf("bad: %s", shares[0])
+	}
+}
@@ -0,0 +1,124 @@
+// Command gfcombine is a drop-in replacement for the gfcombine tool of
+// libgfshare. It combines share files named `name.NNN`, where NNN is the x
+// coordinate of the share, into the secret.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strconv"
+
+	"github.com/corvus-ch/shamir"
+	"github.com/corvus-ch/shamir/internal/getopt"
+)
+
+const usage = `Usage: %s [-o outputfile] inputfile...
+  where outputfile is the file to write the recombined secret to.
+  where inputfile is one of the shares to recombine.
+
+The outputfile option defaults to the first inputfile with the ".NNN"
+suffix removed.
+
+The share number of each input is taken from its ".NNN" suffix.
+`
+
+const progname = "gfcombine"
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// version returns the version of the module the command was built from.
+func version() string {
+	if info, ok := debug.ReadBuildInfo(); ok && "" != info.Main.Version {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	var output string
+
+	opts, args, err := getopt.Parse(args, "hvo:")
+	if nil != err {
+		fmt.Fprintf(stderr, "%s: %v\n", progname, err)
+		fmt.Fprintf(stderr, usage, progname)
+		return 1
+	}
+	for _, opt := range opts {
+		switch opt.Name {
+		case 'h':
+			fmt.Fprintf(stdout, usage, progname)
+			return 0
+		case 'v':
+			fmt.Fprintf(stdout, "%s (github.com/corvus-ch/shamir) %s\n", progname, version())
+			return 0
+		case 'o':
+			output = opt.Arg
+		}
+	}
+	if len(args) < 1 {
+		fmt.Fprintf(stderr, usage, progname)
+		return 1
+	}
+
+	if output == "" {
+		output = args[0][:len(args[0])-len(filepath.Ext(args[0]))]
+	}
+
+	if err := combine(output, args); nil != err {
+		fmt.Fprintf(stderr, "%s: %v\n", progname, err)
+		return 1
+	}
+	return 0
+}
+
+// shareNumber parses the x coordinate from the ".NNN" suffix of the file name.
+func shareNumber(path string) (byte, error) {
+	ext := filepath.Ext(path)
+	if len(ext) != 4 {
+		return 0, fmt.Errorf("unable to determine share number of %s", path)
+	}
+	x, err := strconv.ParseUint(ext[1:], 10, 8)
+	if nil != err || x == 0 {
+		return 0, fmt.Errorf("unable to determine share number of %s", path)
+	}
+	return byte(x), nil
+}
+
+func combine(output string, inputs []string) error {
+	readers := make(map[byte]io.Reader, len(inputs))
+	for _, path := range inputs {
+		x, err := shareNumber(path)
+		if nil != err {
+			return err
+		}
+		if _, exists := readers[x]; exists {
+			return fmt.Errorf("share number %d given more than once", x)
+		}
+		f, err := os.Open(path)
+		if nil != err {
+			return err
+		}
+		defer f.Close()
+		readers[x] = f
+	}
+
+	r, err := shamir.NewReader(readers)
+	if nil != err {
+		return err
+	}
+
+	out, err := os.Create(output)
+	if nil != err {
+		return err
+	}
+	if _, err := io.Copy(out, r); nil != err {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
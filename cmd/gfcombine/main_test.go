@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/corvus-ch/shamir"
+)
+
+var secret = []byte{
+	0xc0, 0x73, 0x62, 0x4a, 0xaf, 0x39, 0x78, 0x51,
+	0x4e, 0xf8, 0x44, 0x3b, 0xb2, 0xa8, 0x59, 0xc7,
+}
+
+func writeShares(t *testing.T, dir string) []string {
+	var names []string
+	parts, err := shamir.Split(secret, 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for x, part := range parts {
+		name := filepath.Join(dir, fmt.Sprintf("secret.%03d", x))
+		if err := ioutil.WriteFile(name, part, 0600); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	names := writeShares(t, dir)
+
+	var stdout, stderr bytes.Buffer
+	if code := run(names[:2], &stdout, &stderr); code != 0 {
+		t.Fatalf("unexpected exit code %d: %s", code, stderr.String())
+	}
+	result, err := ioutil.ReadFile(filepath.Join(dir, "secret"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(result, secret) {
+		t.Fatalf("unexpected result: %v", result)
+	}
+
+	output := filepath.Join(dir, "out")
+	if code := run(append([]string{"-o", output}, names[1:]...), &stdout, &stderr); code != 0 {
+		t.Fatalf("unexpected exit code %d: %s", code, stderr.String())
+	}
+	result, err = ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(result, secret) {
+		t.Fatalf("unexpected result: %v", result)
+	}
+
+	// Like glibc getopt, options may follow the file names.
+	output = filepath.Join(dir, "permuted")
+	if code := run(append(names[1:], "-o", output), &stdout, &stderr); code != 0 {
+		t.Fatalf("unexpected exit code %d: %s", code, stderr.String())
+	}
+	result, err = ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(result, secret) {
+		t.Fatalf("unexpected result: %v", result)
+	}
+}
+
+func TestRun_version(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-v"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("unexpected exit code %d: %s", code, stderr.String())
+	}
+	if !strings.HasPrefix(stdout.String(), "gfcombine (github.com/corvus-ch/shamir) ") {
+		t.Fatalf("bad: %q", stdout.String())
+	}
+}
+
+func TestRun_exitCodes(t *testing.T) {
+	dir := t.TempDir()
+	names := writeShares(t, dir)
+	short := filepath.Join(dir, "short.001")
+	if err := ioutil.WriteFile(short, secret[1:], 0600); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	tests := []struct {
+		code int
+		args []string
+	}{
+		{0, []string{"-h"}},
+		{1, nil},
+		{1, []string{"-o"}},
+		{1, []string{"-x", names[0]}},
+		{1, []string{filepath.Join(dir, "secret")}},
+		{1, []string{filepath.Join(dir, "secret.000"), names[0]}},
+		{1, []string{names[0], names[0]}},
+		{1, []string{"-o", filepath.Join(dir, "out"), names[0], short}},
+		{1, []string{filepath.Join(os.TempDir(), "does-not-exist.001"), names[0]}},
+	}
+	for _, test := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := run(test.args, &stdout, &stderr); code != test.code {
+			t.Errorf("%v: unexpected exit code %d, expected %d", test.args, code, test.code)
+		}
+	}
+}
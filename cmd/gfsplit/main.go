@@ -0,0 +1,139 @@
+// Command gfsplit is a drop-in replacement for the gfsplit tool of
+// libgfshare. It splits a file into shares named `outputstem.NNN`, where NNN
+// is the x coordinate of the share.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+	"strconv"
+
+	"github.com/corvus-ch/shamir"
+	"github.com/corvus-ch/shamir/internal/getopt"
+)
+
+const usage = `Usage: %s [-n threshold] [-m sharecount] inputfile [outputstem]
+  where sharecount is the number of shares to build.
+  where threshold is the number of shares needed to recombine.
+  where inputfile is the file to split.
+  where outputstem is the stem for the output files.
+
+The sharecount option defaults to 5.
+The threshold option defaults to 3.
+The outputstem option defaults to the inputfile.
+
+The program automatically adds ".NNN" to the output stem for each share.
+`
+
+const progname = "gfsplit"
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// version returns the version of the module the command was built from.
+func version() string {
+	if info, ok := debug.ReadBuildInfo(); ok && "" != info.Main.Version {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	threshold, sharecount := 3, 5
+
+	opts, args, err := getopt.Parse(args, "hvn:m:")
+	if nil != err {
+		fmt.Fprintf(stderr, "%s: %v\n", progname, err)
+		fmt.Fprintf(stderr, usage, progname)
+		return 1
+	}
+	for _, opt := range opts {
+		switch opt.Name {
+		case 'h':
+			fmt.Fprintf(stdout, usage, progname)
+			return 0
+		case 'v':
+			fmt.Fprintf(stdout, "%s (github.com/corvus-ch/shamir) %s\n", progname, version())
+			return 0
+		case 'n':
+			if threshold, err = parseCount(opt.Arg); nil != err {
+				fmt.Fprintf(stderr, "%s: Threshold must be between 2 and 255\n", progname)
+				return 1
+			}
+		case 'm':
+			if sharecount, err = parseCount(opt.Arg); nil != err {
+				fmt.Fprintf(stderr, "%s: Share count must be between 2 and 255\n", progname)
+				return 1
+			}
+		}
+	}
+	if threshold > sharecount {
+		fmt.Fprintf(stderr, "%s: Threshold (%d) cannot exceed share count (%d)\n", progname, threshold, sharecount)
+		return 1
+	}
+	if len(args) < 1 || len(args) > 2 {
+		fmt.Fprintf(stderr, usage, progname)
+		return 1
+	}
+
+	input, stem := args[0], args[0]
+	if len(args) == 2 {
+		stem = args[1]
+	}
+
+	if err := split(input, stem, sharecount, threshold); nil != err {
+		fmt.Fprintf(stderr, "%s: %v\n", progname, err)
+		return 1
+	}
+	return 0
+}
+
+// parseCount parses a share count or threshold.
+func parseCount(s string) (int, error) {
+	n, err := strconv.ParseUint(s, 10, 8)
+	if nil != err || n < 2 {
+		return 0, fmt.Errorf("invalid count %q", s)
+	}
+	return int(n), nil
+}
+
+func split(input, stem string, sharecount, threshold int) error {
+	in, err := os.Open(input)
+	if nil != err {
+		return err
+	}
+	defer in.Close()
+
+	var files []*os.File
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+
+	w, err := shamir.NewWriter(sharecount, threshold, func(x byte) (io.Writer, error) {
+		f, err := os.Create(fmt.Sprintf("%s.%03d", stem, x))
+		if nil != err {
+			return nil, err
+		}
+		files = append(files, f)
+		return f, nil
+	})
+	if nil != err {
+		return err
+	}
+	if _, err := io.Copy(w, in); nil != err {
+		return err
+	}
+
+	for _, f := range files {
+		if err := f.Close(); nil != err {
+			return err
+		}
+	}
+	files = nil
+	return nil
+}
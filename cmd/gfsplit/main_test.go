@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/corvus-ch/shamir"
+)
+
+var secret = []byte{
+	0xc0, 0x73, 0x62, 0x4a, 0xaf, 0x39, 0x78, 0x51,
+	0x4e, 0xf8, 0x44, 0x3b, 0xb2, 0xa8, 0x59, 0xc7,
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "secret")
+	if err := ioutil.WriteFile(input, secret, 0600); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-n", "2", "-m3", input, filepath.Join(dir, "stem")}, &stdout, &stderr); code != 0 {
+		t.Fatalf("unexpected exit code %d: %s", code, stderr.String())
+	}
+
+	names, err := filepath.Glob(filepath.Join(dir, "stem.*"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(names) != 3 {
+		t.Fatalf("unexpected shares: %v", names)
+	}
+
+	readers := make(map[byte]io.Reader, 2)
+	for _, name := range names[1:] {
+		x, err := strconv.ParseUint(name[len(name)-3:], 10, 8)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		defer f.Close()
+		readers[byte(x)] = f
+	}
+	r, err := shamir.NewReader(readers)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	result, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(result, secret) {
+		t.Fatalf("unexpected result: %v", result)
+	}
+}
+
+func TestRun_defaults(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "secret")
+	if err := ioutil.WriteFile(input, secret, 0600); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{input}, &stdout, &stderr); code != 0 {
+		t.Fatalf("unexpected exit code %d: %s", code, stderr.String())
+	}
+	names, err := filepath.Glob(input + ".*")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(names) != 5 {
+		t.Fatalf("unexpected shares: %v", names)
+	}
+}
+
+func TestRun_permuted(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "secret")
+	if err := ioutil.WriteFile(input, secret, 0600); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Like glibc getopt, options may follow the file names.
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{input, "-n", "2", filepath.Join(dir, "out"), "-m3"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("unexpected exit code %d: %s", code, stderr.String())
+	}
+	names, err := filepath.Glob(filepath.Join(dir, "out.*"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(names) != 3 {
+		t.Fatalf("unexpected shares: %v", names)
+	}
+}
+
+func TestRun_version(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-v"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("unexpected exit code %d: %s", code, stderr.String())
+	}
+	if !strings.HasPrefix(stdout.String(), "gfsplit (github.com/corvus-ch/shamir) ") {
+		t.Fatalf("bad: %q", stdout.String())
+	}
+}
+
+func TestRun_exitCodes(t *testing.T) {
+	tests := []struct {
+		code int
+		args []string
+	}{
+		{0, []string{"-h"}},
+		{1, nil},
+		{1, []string{"-x", "file"}},
+		{1, []string{"-n", "1", "file"}},
+		{1, []string{"-m", "256", "file"}},
+		{1, []string{"-n", "4", "-m", "3", "file"}},
+		{1, []string{"a", "b", "c"}},
+		{1, []string{filepath.Join(os.TempDir(), "does-not-exist")}},
+	}
+	for _, test := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := run(test.args, &stdout, &stderr); code != test.code {
+			t.Errorf("%v: unexpected exit code %d, expected %d", test.args, code, test.code)
+		}
+	}
+}
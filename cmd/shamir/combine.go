@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/corvus-ch/shamir"
+)
+
+func combine(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("combine", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: shamir combine [flags] [share files]")
+		fs.PrintDefaults()
+	}
+	output := fs.String("o", "-", "file to write the secret to, - for stdout")
+	pattern := fs.String("pattern", defaultPattern, "share file naming pattern used to parse the x coordinates")
+	enc := fs.String("encoding", "raw", "encoding of the shares: raw, hex or base64")
+	if err := fs.Parse(args); nil != err {
+		return errUsage
+	}
+
+	encoding, err := newEncoding(*enc)
+	if nil != err {
+		return err
+	}
+	naming, err := newNaming(*pattern)
+	if nil != err {
+		return err
+	}
+
+	readers := make(map[byte]io.Reader, fs.NArg())
+	if fs.NArg() == 0 {
+		if !encoding.text() {
+			return fmt.Errorf("reading shares from stdin requires a text encoding")
+		}
+		if readers, err = readLines(stdin, encoding); nil != err {
+			return err
+		}
+	}
+	for _, path := range fs.Args() {
+		x, err := naming.x(path)
+		if nil != err {
+			return err
+		}
+		if _, exists := readers[x]; exists {
+			return fmt.Errorf("duplicate share for x coordinate %d", x)
+		}
+		file, err := os.Open(path)
+		if nil != err {
+			return err
+		}
+		defer file.Close()
+		readers[x] = encoding.decoder(file)
+	}
+
+	r, err := shamir.NewReader(readers)
+	if nil != err {
+		return err
+	}
+
+	if *output == "-" {
+		_, err := io.Copy(stdout, r)
+		return err
+	}
+	file, err := os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if nil != err {
+		return err
+	}
+	if _, err := io.Copy(file, r); nil != err {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// readLines reads shares in the form `NNN:data`, one per line.
+func readLines(in io.Reader, encoding encoding) (map[byte]io.Reader, error) {
+	readers := make(map[byte]io.Reader)
+	s := bufio.NewScanner(in)
+	s.Buffer(nil, 1<<30)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+		i := strings.IndexByte(line, ':')
+		if i < 0 {
+			return nil, fmt.Errorf("share must be of the form NNN:data")
+		}
+		x, err := strconv.ParseUint(line[:i], 10, 8)
+		if nil != err || x == 0 {
+			return nil, fmt.Errorf("invalid x coordinate %q", line[:i])
+		}
+		if _, exists := readers[byte(x)]; exists {
+			return nil, fmt.Errorf("duplicate share for x coordinate %d", x)
+		}
+		readers[byte(x)] = encoding.decoder(strings.NewReader(line[i+1:]))
+	}
+	return readers, s.Err()
+}
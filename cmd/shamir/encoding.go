@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// encoding converts the shares to and from their textual representation.
+type encoding interface {
+	// encoder returns a writer encoding into w. Closing the writer flushes
+	// the encoding but does not close w.
+	encoder(w io.Writer) io.WriteCloser
+	// decoder returns a reader decoding r. Whitespace is ignored.
+	decoder(r io.Reader) io.Reader
+	// text reports whether the encoding is safe to print.
+	text() bool
+}
+
+func newEncoding(name string) (encoding, error) {
+	switch name {
+	case "raw":
+		return rawEncoding{}, nil
+	case "hex":
+		return hexEncoding{}, nil
+	case "base64":
+		return base64Encoding{}, nil
+	}
+	return nil, fmt.Errorf("unknown encoding %q, must be one of raw, hex or base64", name)
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+type rawEncoding struct{}
+
+func (rawEncoding) encoder(w io.Writer) io.WriteCloser { return nopCloser{w} }
+func (rawEncoding) decoder(r io.Reader) io.Reader      { return r }
+func (rawEncoding) text() bool                         { return false }
+
+type hexEncoding struct{}
+
+func (hexEncoding) encoder(w io.Writer) io.WriteCloser {
+	return &lineCloser{WriteCloser: nopCloser{hex.NewEncoder(w)}, w: w}
+}
+func (hexEncoding) decoder(r io.Reader) io.Reader { return hex.NewDecoder(stripSpace(r)) }
+func (hexEncoding) text() bool                    { return true }
+
+type base64Encoding struct{}
+
+func (base64Encoding) encoder(w io.Writer) io.WriteCloser {
+	return &lineCloser{WriteCloser: base64.NewEncoder(base64.StdEncoding, w), w: w}
+}
+func (base64Encoding) decoder(r io.Reader) io.Reader {
+	return base64.NewDecoder(base64.StdEncoding, stripSpace(r))
+}
+func (base64Encoding) text() bool { return true }
+
+// lineCloser terminates the encoded output with a newline on Close.
+type lineCloser struct {
+	io.WriteCloser
+	w io.Writer
+}
+
+func (l *lineCloser) Close() error {
+	if err := l.WriteCloser.Close(); nil != err {
+		return err
+	}
+	_, err := io.WriteString(l.w, "\n")
+	return err
+}
+
+// stripSpace returns a reader skipping all ASCII white space of r.
+func stripSpace(r io.Reader) io.Reader {
+	return spaceStripper{r}
+}
+
+type spaceStripper struct {
+	r io.Reader
+}
+
+func (s spaceStripper) Read(p []byte) (int, error) {
+	for {
+		n, err := s.r.Read(p)
+		m := 0
+		for _, c := range p[:n] {
+			switch c {
+			case ' ', '\t', '\n', '\r', '\v', '\f':
+			default:
+				p[m] = c
+				m++
+			}
+		}
+		if m > 0 || nil != err || len(p) == 0 {
+			return m, err
+		}
+	}
+}
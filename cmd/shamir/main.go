@@ -0,0 +1,63 @@
+// Command shamir splits a secret into shares and combines shares back into
+// the secret.
+//
+// Usage:
+//
+//	shamir split [flags] [file]
+//	shamir combine [flags] [share files]
+//
+// Without a file, split reads the secret from stdin. By default, the shares
+// are written next to the input using the libgfshare naming scheme
+// `name.NNN`, where NNN is the x coordinate of the share. Combine parses the x
+// coordinate from the share file names using the same scheme. With -stdout,
+// split writes the shares to stdout, one per line in the form `NNN:data`,
+// and combine reads them from stdin in that form when no files are given.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `usage: shamir <command> [flags] [arguments]
+
+commands:
+  split    split a secret into shares
+  combine  combine shares into the secret
+
+Run "shamir <command> -h" for the flags of a command.
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) < 1 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	var err error
+	switch args[0] {
+	case "split":
+		err = split(args[1:], stdin, stdout, stderr)
+	case "combine":
+		err = combine(args[1:], stdin, stdout, stderr)
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "shamir: unknown command %q\n%s", args[0], usage)
+		return 2
+	}
+
+	if errUsage == err {
+		return 2
+	} else if nil != err {
+		fmt.Fprintf(stderr, "shamir %s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
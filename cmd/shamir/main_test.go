@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+var secret = []byte{
+	0xc0, 0x73, 0x62, 0x4a, 0xaf, 0x39, 0x78, 0x51,
+	0x4e, 0xf8, 0x44, 0x3b, 0xb2, 0xa8, 0x59, 0xc7,
+}
+
+func runCmd(t *testing.T, stdin []byte, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, bytes.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestSplitCombine_files(t *testing.T) {
+	for _, enc := range []string{"raw", "hex", "base64"} {
+		t.Run(enc, func(t *testing.T) {
+			dir := t.TempDir()
+			input := filepath.Join(dir, "key.bin")
+			if err := ioutil.WriteFile(input, secret, 0600); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			if code, _, stderr := runCmd(t, nil, "split", "-parts", "4", "-threshold", "2", "-encoding", enc, input); code != 0 {
+				t.Fatalf("split failed with %d: %s", code, stderr)
+			}
+
+			names, err := filepath.Glob(input + ".*")
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if len(names) != 4 {
+				t.Fatalf("unexpected shares: %v", names)
+			}
+
+			output := filepath.Join(dir, "out")
+			if code, _, stderr := runCmd(t, nil, "combine", "-encoding", enc, "-o", output, names[0], names[3]); code != 0 {
+				t.Fatalf("combine failed with %d: %s", code, stderr)
+			}
+			result, err := ioutil.ReadFile(output)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if !bytes.Equal(result, secret) {
+				t.Fatalf("unexpected result: %v", result)
+			}
+		})
+	}
+}
+
+func TestSplitCombine_stdout(t *testing.T) {
+	code, stdout, stderr := runCmd(t, secret, "split", "-parts", "3", "-threshold", "2", "-encoding", "hex", "-stdout")
+	if code != 0 {
+		t.Fatalf("split failed with %d: %s", code, stderr)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("unexpected output: %s", stdout)
+	}
+
+	code, stdout, stderr = runCmd(t, []byte(lines[0]+"\n\n"+lines[2]+"\n"), "combine", "-encoding", "hex")
+	if code != 0 {
+		t.Fatalf("combine failed with %d: %s", code, stderr)
+	}
+	if stdout != string(secret) {
+		t.Fatalf("unexpected result: %v", []byte(stdout))
+	}
+}
+
+func TestSplit_pattern(t *testing.T) {
+	dir := t.TempDir()
+	code, _, stderr := runCmd(t, secret, "split", "-dir", dir, "-name", "backup", "-pattern", "{name}-share-{x}.txt", "-encoding", "base64")
+	if code != 0 {
+		t.Fatalf("split failed with %d: %s", code, stderr)
+	}
+
+	names, err := filepath.Glob(filepath.Join(dir, "backup-share-*.txt"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(names) != 5 {
+		t.Fatalf("unexpected shares: %v", names)
+	}
+
+	args := append([]string{"combine", "-pattern", "{name}-share-{x}.txt", "-encoding", "base64"}, names[:3]...)
+	code, stdout, stderr := runCmd(t, nil, args...)
+	if code != 0 {
+		t.Fatalf("combine failed with %d: %s", code, stderr)
+	}
+	if stdout != string(secret) {
+		t.Fatalf("unexpected result: %v", []byte(stdout))
+	}
+}
+
+func TestSplit_cleanup(t *testing.T) {
+	dir := t.TempDir()
+	naming, err := newNaming(defaultPattern)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	encoding, err := newEncoding("raw")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	in := io.MultiReader(bytes.NewReader(secret), iotest.ErrReader(errors.New("read failed")))
+	if err := splitToFiles(in, dir, "secret", naming, 3, 2, encoding); err == nil {
+		t.Fatalf("expect error")
+	}
+	names, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(names) != 0 {
+		t.Fatalf("unexpected files: %v", names)
+	}
+}
+
+func TestRun_errors(t *testing.T) {
+	tests := []struct {
+		code int
+		args []string
+	}{
+		{2, nil},
+		{2, []string{"unknown"}},
+		{2, []string{"split", "-unknown"}},
+		{1, []string{"split", "-encoding", "rot13"}},
+		{1, []string{"split", "-stdout"}},
+		{1, []string{"split", "-pattern", "{name}"}},
+		{1, []string{"split", "-parts", "2", "-threshold", "3", "-stdout", "-encoding", "hex"}},
+		{1, []string{"split", filepath.Join(os.TempDir(), "does-not-exist")}},
+		{1, []string{"combine"}},
+		{1, []string{"combine", "secret.txt"}},
+	}
+	for _, test := range tests {
+		if code, _, _ := runCmd(t, nil, test.args...); code != test.code {
+			t.Errorf("%v: unexpected exit code %d, expected %d", test.args, code, test.code)
+		}
+	}
+}
+
+func TestNaming(t *testing.T) {
+	n, err := newNaming(defaultPattern)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if name := n.name("secret.txt", 7); name != "secret.txt.007" {
+		t.Fatalf("bad: %s", name)
+	}
+	if x, err := n.x("/tmp/secret.txt.042"); err != nil || x != 42 {
+		t.Fatalf("bad: %d %v", x, err)
+	}
+	for _, path := range []string{"secret.txt", "secret.000", "secret.256", "secret.12"} {
+		if _, err := n.x(path); err == nil {
+			t.Errorf("%s: expect error", path)
+		}
+	}
+}
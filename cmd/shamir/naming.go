@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// defaultPattern is the libgfshare naming scheme.
+const defaultPattern = "{name}.{x}"
+
+// naming maps x coordinates to share file names and back. The pattern may
+// contain the placeholders {name}, replaced by the name of the secret, and
+// {x}, replaced by the x coordinate as three digit decimal number.
+type naming struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+func newNaming(pattern string) (*naming, error) {
+	if strings.Count(pattern, "{x}") != 1 {
+		return nil, fmt.Errorf("pattern must contain {x} exactly once")
+	}
+	if strings.ContainsRune(pattern, filepath.Separator) {
+		return nil, fmt.Errorf("pattern must not contain a path separator")
+	}
+
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.Replace(expr, regexp.QuoteMeta("{name}"), ".*", -1)
+	expr = strings.Replace(expr, regexp.QuoteMeta("{x}"), "([0-9]{3})", 1)
+	re, err := regexp.Compile("^" + expr + "$")
+	if nil != err {
+		return nil, err
+	}
+	return &naming{pattern: pattern, re: re}, nil
+}
+
+// name returns the file name of the share with the x coordinate.
+func (n *naming) name(name string, x byte) string {
+	s := strings.Replace(n.pattern, "{name}", name, -1)
+	return strings.Replace(s, "{x}", fmt.Sprintf("%03d", x), 1)
+}
+
+// x parses the x coordinate from the file name of a share.
+func (n *naming) x(path string) (byte, error) {
+	m := n.re.FindStringSubmatch(filepath.Base(path))
+	if nil == m {
+		return 0, fmt.Errorf("%s does not match the share naming pattern %q", path, n.pattern)
+	}
+	x, err := strconv.ParseUint(m[1], 10, 8)
+	if nil != err || x == 0 {
+		return 0, fmt.Errorf("%s has an invalid x coordinate", path)
+	}
+	return byte(x), nil
+}
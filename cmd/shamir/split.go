@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/corvus-ch/shamir"
+)
+
+// errUsage signals a usage error already reported by the flag set.
+var errUsage = errors.New("usage")
+
+func split(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("split", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: shamir split [flags] [file]")
+		fs.PrintDefaults()
+	}
+	parts := fs.Int("parts", 5, "number of shares to create")
+	threshold := fs.Int("threshold", 3, "number of shares required to reconstruct the secret")
+	dir := fs.String("dir", "", "directory to write the shares to (default: directory of the input file or the current directory)")
+	name := fs.String("name", "", "name of the secret used in the share file names (default: name of the input file or \"secret\")")
+	pattern := fs.String("pattern", defaultPattern, "share file naming pattern, {name} and {x} are replaced by the name of the secret and the x coordinate")
+	enc := fs.String("encoding", "raw", "encoding of the shares: raw, hex or base64")
+	toStdout := fs.Bool("stdout", false, "write the shares to stdout, one per line, instead of to files")
+	if err := fs.Parse(args); nil != err {
+		return errUsage
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	encoding, err := newEncoding(*enc)
+	if nil != err {
+		return err
+	}
+	naming, err := newNaming(*pattern)
+	if nil != err {
+		return err
+	}
+
+	in := stdin
+	if path := fs.Arg(0); path != "" && path != "-" {
+		file, err := os.Open(path)
+		if nil != err {
+			return err
+		}
+		defer file.Close()
+		in = file
+		if *name == "" {
+			*name = filepath.Base(path)
+		}
+		if *dir == "" {
+			*dir = filepath.Dir(path)
+		}
+	}
+	if *name == "" {
+		*name = "secret"
+	}
+	if *dir == "" {
+		*dir = "."
+	}
+
+	if *toStdout {
+		return splitToStdout(in, stdout, *parts, *threshold, encoding)
+	}
+	return splitToFiles(in, *dir, *name, naming, *parts, *threshold, encoding)
+}
+
+// splitToFiles streams the secret into one file per share. If the split
+// fails, the share files created so far are removed again, so a partial set
+// of shares is not mistaken for a complete one.
+func splitToFiles(in io.Reader, dir, name string, naming *naming, parts, threshold int, encoding encoding) (err error) {
+	var files []*os.File
+	var encoders []io.WriteCloser
+	defer func() {
+		if nil == err {
+			return
+		}
+		for _, f := range files {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	w, err := shamir.NewWriter(parts, threshold, func(x byte) (io.Writer, error) {
+		f, err := os.OpenFile(filepath.Join(dir, naming.name(name, x)), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if nil != err {
+			return nil, err
+		}
+		files = append(files, f)
+		e := encoding.encoder(f)
+		encoders = append(encoders, e)
+		return e, nil
+	})
+	if nil != err {
+		return err
+	}
+
+	if _, err := io.Copy(w, in); nil != err {
+		return err
+	}
+	for _, e := range encoders {
+		if err := e.Close(); nil != err {
+			return err
+		}
+	}
+	for _, f := range files {
+		if err := f.Close(); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitToStdout writes all shares to out, one per line.
+func splitToStdout(in io.Reader, out io.Writer, parts, threshold int, encoding encoding) error {
+	if !encoding.text() {
+		return fmt.Errorf("writing shares to stdout requires a text encoding")
+	}
+
+	secret, err := ioutil.ReadAll(in)
+	if nil != err {
+		return err
+	}
+	shares, err := shamir.Split(secret, parts, threshold)
+	if nil != err {
+		return err
+	}
+
+	xs := make([]int, 0, len(shares))
+	for x := range shares {
+		xs = append(xs, int(x))
+	}
+	sort.Ints(xs)
+
+	for _, x := range xs {
+		var buf bytes.Buffer
+		e := encoding.encoder(&buf)
+		if _, err := e.Write(shares[byte(x)]); nil != err {
+			return err
+		}
+		if err := e.Close(); nil != err {
+			return err
+		}
+		if _, err := fmt.Fprintf(out, "%03d:%s\n", x, strings.TrimSpace(buf.String())); nil != err {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package shamir
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// checkDerive ensures x can be used for a new share next to the x coordinates
+// in use.
+func checkDerive(x byte, used func(x byte) bool) error {
+	if x == 0 {
+		return fmt.Errorf("x coordinate cannot be zero")
+	}
+	if used(x) {
+		return fmt.Errorf("x coordinate %d is already in use", x)
+	}
+	return nil
+}
+
+// DeriveShare computes the share for the x coordinate x from at least
+// `threshold` existing parts, for example to replace a lost share without
+// changing any of the others. The new share is identical to the one Split
+// would have computed for x.
+//
+// The parts must include every share in use, as x is refused if it is zero
+// or one of their coordinates. Giving fewer parts than the threshold results
+// in a share inconsistent with the others.
+func DeriveShare(parts map[byte][]byte, x byte, opts ...Option) ([]byte, error) {
+	if err := checkDerive(x, func(x byte) bool { _, ok := parts[x]; return ok }); nil != err {
+		return nil, err
+	}
+	return interpolateParts(parts, x, newConfig(opts).field)
+}
+
+// NewDeriveReader works like DeriveShare but reads the parts from readers and
+// returns a reader for the new share.
+func NewDeriveReader(readers map[byte]io.Reader, x byte, opts ...Option) (io.Reader, error) {
+	if len(readers) < 2 {
+		return nil, fmt.Errorf("at least two parts are required to derive a share")
+	}
+	if err := checkDerive(x, func(x byte) bool { _, ok := readers[x]; return ok }); nil != err {
+		return nil, err
+	}
+
+	return newReader(readers, x, opts), nil
+}
+
+// DeriveShares works like DeriveShare but takes and returns shares in their
+// self-describing form. The shares are checked to belong to the same share
+// set and to be at least as many as their threshold.
+//
+// The tag of the new share is derived along with its value, so the secret can
+// still be verified when the new share is combined with the others.
+func DeriveShares(shares []*Share, x byte) (*Share, error) {
+	hs := make([]header, len(shares))
+	for i, s := range shares {
+		hs[i] = s.header()
+	}
+	if err := checkHeaders(hs); nil != err {
+		return nil, err
+	}
+
+	parts := make(map[byte][]byte, len(shares))
+	for _, s := range shares {
+		if nil != s.Tag && len(s.Tag) != shareTagSize {
+			return nil, fmt.Errorf("tag must be %d bytes long", shareTagSize)
+		}
+		parts[s.X] = append(append(make([]byte, 0, len(s.Value)+len(s.Tag)), s.Value...), s.Tag...)
+	}
+
+	value, err := DeriveShare(parts, x, WithField(hs[0].field))
+	if nil != err {
+		return nil, err
+	}
+
+	n := len(shares[0].Value)
+	out := &Share{
+		X:         x,
+		Threshold: shares[0].Threshold,
+		SetID:     shares[0].SetID,
+		Field:     shares[0].Field,
+		Value:     value[:n:n],
+		Salt:      shares[0].Salt,
+	}
+	if nil != shares[0].Tag {
+		out.Tag = value[n:]
+	}
+	return out, nil
+}
+
+// NewDeriveShareReader works like DeriveShares but reads the shares in their
+// self-describing form, as written by NewShareWriter or Share.MarshalBinary,
+// and returns a reader for the new share in the same form.
+//
+// The headers of all shares are read before NewDeriveShareReader returns. A
+// *SetMismatchError is returned if they do not belong to the same share set
+// and ErrNotEnoughShares if fewer shares than the recorded threshold are
+// given.
+func NewDeriveShareReader(x byte, readers ...io.Reader) (io.Reader, error) {
+	parts := make(map[byte]io.Reader, len(readers))
+	hs := make([]header, 0, len(readers))
+	for _, r := range readers {
+		h, err := readHeader(r)
+		if nil != err {
+			return nil, err
+		}
+		if h.length != 0 {
+			r = &lengthReader{r: r, n: h.length + uint64(h.tagSize())}
+		}
+		parts[h.x] = r
+		hs = append(hs, h)
+	}
+	if err := checkHeaders(hs); nil != err {
+		return nil, err
+	}
+
+	r, err := NewDeriveReader(parts, x, WithField(hs[0].field))
+	if nil != err {
+		return nil, err
+	}
+
+	// The new share has the same header as the others, apart from its x
+	// coordinate.
+	h := hs[0]
+	h.x = x
+	return io.MultiReader(bytes.NewReader(h.marshal()), r), nil
+}
@@ -0,0 +1,170 @@
+package shamir
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+func TestDeriveShare(t *testing.T) {
+	secret := []byte("test")
+
+	out, err := Split(secret, 5, 3, WithCoordinates(1, 2, 3, 4, 5))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	lost := out[5]
+	delete(out, 5)
+
+	parts := map[byte][]byte{1: out[1], 2: out[2], 3: out[3]}
+	for _, x := range []byte{5, 42} {
+		derived, err := DeriveShare(parts, x)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if x == 5 && !bytes.Equal(derived, lost) {
+			t.Fatalf("bad: %v %v", derived, lost)
+		}
+
+		recomb, err := Combine(map[byte][]byte{x: derived, 1: out[1], 4: out[4]})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !bytes.Equal(recomb, secret) {
+			t.Fatalf("bad: %v %v", recomb, secret)
+		}
+	}
+}
+
+func TestDeriveShare_invalid(t *testing.T) {
+	parts := map[byte][]byte{1: {1}, 2: {2}}
+
+	for _, x := range []byte{0, 1, 2} {
+		if _, err := DeriveShare(parts, x); err == nil {
+			t.Errorf("%d: expect error", x)
+		}
+	}
+	if _, err := DeriveShare(map[byte][]byte{1: {1}}, 3); err == nil {
+		t.Fatalf("expect error")
+	}
+	if _, err := DeriveShare(map[byte][]byte{1: {1}, 2: {1, 2}}, 3); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestDeriveReader(t *testing.T) {
+	secret := bytes.Repeat([]byte("test"), blockSize)
+
+	out, err := Split(secret, 3, 2, WithField(AESField), WithCoordinates(1, 2, 3))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	readers := make(map[byte]io.Reader, len(out))
+	for x, part := range out {
+		readers[x] = bytes.NewReader(part)
+	}
+	exp, err := DeriveShare(out, 200, WithField(AESField))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	r, err := NewDeriveReader(readers, 200, WithField(AESField))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	derived, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(derived, exp) {
+		t.Fatal("bad")
+	}
+}
+
+func TestDeriveReader_invalid(t *testing.T) {
+	readers := map[byte]io.Reader{1: &bytes.Buffer{}, 2: &bytes.Buffer{}}
+
+	for _, x := range []byte{0, 1, 2} {
+		if _, err := NewDeriveReader(readers, x); err == nil {
+			t.Errorf("%d: expect error", x)
+		}
+	}
+	if _, err := NewDeriveReader(map[byte]io.Reader{1: &bytes.Buffer{}}, 3); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestDeriveShares(t *testing.T) {
+	secret := []byte("test")
+
+	shares, err := SplitShares(secret, 3, 2, WithField(AESField), WithCoordinates(1, 2, 3))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	x := byte(4)
+
+	derived, err := DeriveShares(shares[:2], x)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if derived.X != x || derived.SetID != shares[0].SetID || derived.Field != AESField || len(derived.Tag) != shareTagSize {
+		t.Fatalf("bad: %v", derived)
+	}
+
+	recomb, err := CombineShares([]*Share{shares[2], derived})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recomb, secret) {
+		t.Fatalf("bad: %v %v", recomb, secret)
+	}
+
+	if _, err := DeriveShares(shares[:1], x); err != ErrNotEnoughShares {
+		t.Fatalf("bad: %v", err)
+	}
+	if _, err := DeriveShares(shares[:2], shares[1].X); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestDeriveShareReader(t *testing.T) {
+	secret := []byte("test")
+
+	shares, err := SplitShares(secret, 2, 2, WithCoordinates(1, 2))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	readers := make([]io.Reader, len(shares))
+	for i, s := range shares {
+		data, err := s.MarshalBinary()
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		readers[i] = bytes.NewReader(data)
+	}
+
+	r, err := NewDeriveShareReader(3, readers...)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var derived Share
+	if err := derived.UnmarshalBinary(data); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if derived.X != 3 {
+		t.Fatalf("bad: %v", derived)
+	}
+
+	recomb, err := CombineShares([]*Share{shares[0], &derived})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recomb, secret) {
+		t.Fatalf("bad: %v %v", recomb, secret)
+	}
+}
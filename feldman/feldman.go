@@ -0,0 +1,272 @@
+// Package feldman implements Feldman's verifiable secret sharing.
+//
+// Unlike the GF(2^8) based splitting of the parent package, the shares are
+// computed over the prime field of the exponents of a Schnorr group. The
+// dealer publishes commitments to the coefficients of the polynomial, which
+// allow every shareholder to verify their share without learning the secret.
+//
+// The first commitment is g^secret. It does not reveal the secret as long as
+// computing discrete logarithms in the group is hard, but it allows to test
+// guesses of the secret. Only use this package for secrets with enough entropy
+// to rule out guessing, such as keys.
+package feldman
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+)
+
+// ErrInvalidShare is returned by Verify if a share is not consistent with the
+// commitments.
+var ErrInvalidShare = errors.New("share does not match the commitments")
+
+// Group is a Schnorr group, the subgroup of order Q of the multiplicative
+// group of integers modulo P, generated by G.
+type Group struct {
+	P, Q, G *big.Int
+}
+
+// modp2048 is the 2048-bit MODP group of RFC 3526, section 3.
+const modp2048 = `
+	FFFFFFFF FFFFFFFF C90FDAA2 2168C234 C4C6628B 80DC1CD1
+	29024E08 8A67CC74 020BBEA6 3B139B22 514A0879 8E3404DD
+	EF9519B3 CD3A431B 302B0A6D F25F1437 4FE1356D 6D51C245
+	E485B576 625E7EC6 F44C42E9 A637ED6B 0BFF5CB6 F406B7ED
+	EE386BFB 5A899FA5 AE9F2411 7C4B1FE6 49286651 ECE45B3D
+	C2007CB8 A163BF05 98DA4836 1C55D39A 69163FA8 FD24CF5F
+	83655D23 DCA3AD96 1C62F356 208552BB 9ED52907 7096966D
+	670C354E 4ABC9804 F1746C08 CA18217C 32905E46 2E36CE3B
+	E39E772C 180E8603 9B2783A2 EC07A28F B5C55DF0 6F4C52C9
+	DE2BCBF6 95581718 3995497C EA956AE5 15D22618 98FA0510
+	15728E5A 8AACAA68 FFFFFFFF FFFFFFFF`
+
+// DefaultGroup returns the group built from the 2048-bit MODP prime P of
+// RFC 3526. P is a safe prime, so Q = (P-1)/2 is prime as well, and 2
+// generates the subgroup of quadratic residues of order Q.
+func DefaultGroup() *Group {
+	p, _ := new(big.Int).SetString(strings.Join(strings.Fields(modp2048), ""), 16)
+	q := new(big.Int).Rsh(p, 1)
+	return &Group{P: p, Q: q, G: big.NewInt(2)}
+}
+
+// NewGroup returns the group defined by p, q and g after checking that p and q
+// are prime, q divides p-1 and g generates the subgroup of order q.
+func NewGroup(p, q, g *big.Int) (*Group, error) {
+	one := big.NewInt(1)
+	if !p.ProbablyPrime(32) || !q.ProbablyPrime(32) {
+		return nil, fmt.Errorf("p and q must be prime")
+	}
+	pm1 := new(big.Int).Sub(p, one)
+	if new(big.Int).Mod(pm1, q).Sign() != 0 {
+		return nil, fmt.Errorf("q must divide p-1")
+	}
+	if g.Cmp(one) <= 0 || g.Cmp(p) >= 0 {
+		return nil, fmt.Errorf("g must be between 1 and p")
+	}
+	if new(big.Int).Exp(g, q, p).Cmp(one) != 0 {
+		return nil, fmt.Errorf("g must generate the subgroup of order q")
+	}
+	return &Group{P: p, Q: q, G: g}, nil
+}
+
+// MaxSecretLen returns the length of the longest secret which can be split
+// within the group.
+func (g *Group) MaxSecretLen() int {
+	// The secret is prefixed with a byte of value 1 to preserve leading
+	// zeros, and the result must be less than Q.
+	return (g.Q.BitLen() - 2) / 8
+}
+
+// Share is a single share of the secret, the value Y of the polynomial at X.
+type Share struct {
+	X int
+	Y *big.Int
+}
+
+// Option configures the optional behaviour of Split.
+type Option func(*config)
+
+type config struct {
+	rand io.Reader
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{rand: rand.Reader}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithRand sets the source of randomness used to pick the coefficients of the
+// polynomial. It defaults to crypto/rand.Reader.
+//
+// The security of the shares depends entirely on the quality of this source.
+// Use anything other than a cryptographically secure random number generator
+// for testing only.
+func WithRand(r io.Reader) Option {
+	return func(c *config) {
+		c.rand = r
+	}
+}
+
+// Commitments holds g^a for every coefficient a of the polynomial, starting
+// with the constant term.
+type Commitments []*big.Int
+
+// Split takes a secret and generates a `parts` number of shares, `threshold`
+// of which are required to reconstruct the secret, together with the
+// commitments used to verify them. The shares use the x coordinates 1 to
+// parts. The secret must not be longer than group.MaxSecretLen() bytes.
+func Split(group *Group, secret []byte, parts, threshold int, opts ...Option) ([]Share, Commitments, error) {
+	cfg := newConfig(opts)
+
+	// Sanity check the input
+	if parts < threshold {
+		return nil, nil, fmt.Errorf("parts cannot be less than threshold")
+	}
+	if threshold < 2 {
+		return nil, nil, fmt.Errorf("threshold must be at least 2")
+	}
+	if len(secret) > group.MaxSecretLen() {
+		return nil, nil, fmt.Errorf("secret cannot exceed %d bytes", group.MaxSecretLen())
+	}
+
+	// Prefix the secret to preserve its leading zeros.
+	coeffs := make([]*big.Int, threshold)
+	coeffs[0] = new(big.Int).SetBytes(append([]byte{1}, secret...))
+	for i := 1; i < threshold; i++ {
+		c, err := randInt(cfg.rand, group.Q)
+		if nil != err {
+			return nil, nil, fmt.Errorf("failed to generate polynomial: %v", err)
+		}
+		coeffs[i] = c
+	}
+
+	commitments := make(Commitments, threshold)
+	for i, c := range coeffs {
+		commitments[i] = new(big.Int).Exp(group.G, c, group.P)
+	}
+
+	shares := make([]Share, parts)
+	for i := range shares {
+		x := big.NewInt(int64(i + 1))
+		// Compute the polynomial value using Horner's method.
+		y := new(big.Int).Set(coeffs[threshold-1])
+		for j := threshold - 2; j >= 0; j-- {
+			y.Mul(y, x)
+			y.Add(y, coeffs[j])
+			y.Mod(y, group.Q)
+		}
+		shares[i] = Share{X: i + 1, Y: y}
+	}
+
+	return shares, commitments, nil
+}
+
+// randInt returns a uniform random integer in [0, max).
+func randInt(r io.Reader, max *big.Int) (*big.Int, error) {
+	b := make([]byte, (max.BitLen()+7)/8)
+	excess := uint(len(b)*8 - max.BitLen())
+	for {
+		if _, err := io.ReadFull(r, b); nil != err {
+			return nil, err
+		}
+		b[0] &= byte(0xff >> excess)
+		n := new(big.Int).SetBytes(b)
+		if n.Cmp(max) < 0 {
+			return n, nil
+		}
+	}
+}
+
+// Verify checks the share against the commitments published by the dealer
+// for a split with the given threshold. It returns ErrInvalidShare if the
+// share does not lie on the committed polynomial.
+//
+// The commitments must be exactly threshold elements of the subgroup of order
+// Q. A dealer publishing more commitments would otherwise raise the threshold
+// unnoticed, and elements outside the subgroup could make invalid shares pass.
+func Verify(group *Group, share Share, commitments Commitments, threshold int) error {
+	if threshold < 2 {
+		return fmt.Errorf("threshold must be at least 2")
+	}
+	if len(commitments) != threshold {
+		return fmt.Errorf("expected %d commitments, got %d", threshold, len(commitments))
+	}
+	one := big.NewInt(1)
+	for i, c := range commitments {
+		if nil == c || c.Cmp(one) < 0 || c.Cmp(group.P) >= 0 || new(big.Int).Exp(c, group.Q, group.P).Cmp(one) != 0 {
+			return fmt.Errorf("commitment %d is not an element of the group", i)
+		}
+	}
+	if share.X < 1 || nil == share.Y || share.Y.Sign() < 0 || share.Y.Cmp(group.Q) >= 0 {
+		return ErrInvalidShare
+	}
+
+	// g^y must equal the product of c_j^(x^j).
+	x := big.NewInt(int64(share.X))
+	e := big.NewInt(1)
+	expected := big.NewInt(1)
+	for _, c := range commitments {
+		expected.Mul(expected, new(big.Int).Exp(c, e, group.P))
+		expected.Mod(expected, group.P)
+		e.Mul(e, x)
+		e.Mod(e, group.Q)
+	}
+
+	if new(big.Int).Exp(group.G, share.Y, group.P).Cmp(expected) != 0 {
+		return ErrInvalidShare
+	}
+	return nil
+}
+
+// Combine is used to reverse a Split and reconstruct the secret once a
+// `threshold` number of shares are available. Use Verify to check the shares
+// beforehand, Combine has no way to tell if the result is correct.
+func Combine(group *Group, shares []Share) ([]byte, error) {
+	// Verify enough parts provided
+	if len(shares) < 2 {
+		return nil, fmt.Errorf("less than two parts cannot be used to reconstruct the secret")
+	}
+	seen := make(map[int]bool, len(shares))
+	for _, s := range shares {
+		if s.X < 1 || nil == s.Y {
+			return nil, fmt.Errorf("invalid share")
+		}
+		if seen[s.X] {
+			return nil, fmt.Errorf("duplicate share for x coordinate %d", s.X)
+		}
+		seen[s.X] = true
+	}
+
+	// Lagrange interpolation at x = 0.
+	secret := new(big.Int)
+	for i, a := range shares {
+		num := big.NewInt(1)
+		den := big.NewInt(1)
+		for j, b := range shares {
+			if i != j {
+				num.Mul(num, big.NewInt(int64(-b.X)))
+				num.Mod(num, group.Q)
+				den.Mul(den, big.NewInt(int64(a.X-b.X)))
+				den.Mod(den, group.Q)
+			}
+		}
+		den.ModInverse(den, group.Q)
+		term := new(big.Int).Mul(a.Y, num)
+		term.Mul(term, den)
+		secret.Add(secret, term)
+		secret.Mod(secret, group.Q)
+	}
+
+	b := secret.Bytes()
+	if len(b) == 0 || b[0] != 1 {
+		return nil, fmt.Errorf("failed to reconstruct the secret")
+	}
+	return b[1:], nil
+}
@@ -0,0 +1,171 @@
+package feldman
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+func TestDefaultGroup(t *testing.T) {
+	g := DefaultGroup()
+	if _, err := NewGroup(g.P, g.Q, g.G); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if g.MaxSecretLen() != 255 {
+		t.Fatalf("bad: %d", g.MaxSecretLen())
+	}
+}
+
+func TestNewGroup_invalid(t *testing.T) {
+	// p = 2q + 1 with q = 11, the quadratic residues are generated by 4.
+	p, q := big.NewInt(23), big.NewInt(11)
+	small, err := NewGroup(p, q, big.NewInt(4))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if small.MaxSecretLen() != 0 {
+		t.Fatalf("bad: %d", small.MaxSecretLen())
+	}
+
+	tests := []struct{ p, q, g int64 }{
+		{24, 11, 4}, // p not prime
+		{23, 7, 4},  // q does not divide p-1
+		{23, 11, 1}, // trivial generator
+		{23, 11, 5}, // 5 is not a quadratic residue mod 23
+	}
+	for _, test := range tests {
+		if _, err := NewGroup(big.NewInt(test.p), big.NewInt(test.q), big.NewInt(test.g)); err == nil {
+			t.Errorf("expect error for %v", test)
+		}
+	}
+}
+
+func TestSplit_invalid(t *testing.T) {
+	g := DefaultGroup()
+	secret := []byte("test")
+
+	if _, _, err := Split(g, secret, 2, 3); err == nil {
+		t.Fatalf("expect error")
+	}
+	if _, _, err := Split(g, secret, 3, 1); err == nil {
+		t.Fatalf("expect error")
+	}
+	if _, _, err := Split(g, make([]byte, 256), 3, 2); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestSplitVerifyCombine(t *testing.T) {
+	g := DefaultGroup()
+	secret := []byte{0, 0, 't', 'e', 's', 't'}
+
+	shares, commitments, err := Split(g, secret, 5, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(shares) != 5 || len(commitments) != 3 {
+		t.Fatalf("bad: %v %v", shares, commitments)
+	}
+
+	for _, s := range shares {
+		if err := Verify(g, s, commitments, 3); err != nil {
+			t.Fatalf("share %d: %v", s.X, err)
+		}
+	}
+
+	recomb, err := Combine(g, []Share{shares[4], shares[0], shares[2]})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recomb, secret) {
+		t.Fatalf("bad: %v %v", recomb, secret)
+	}
+}
+
+func TestVerify_invalid(t *testing.T) {
+	g := DefaultGroup()
+
+	shares, commitments, err := Split(g, []byte("test"), 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	tampered := Share{X: shares[0].X, Y: new(big.Int).Add(shares[0].Y, big.NewInt(1))}
+	if err := Verify(g, tampered, commitments, 2); err != ErrInvalidShare {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	moved := Share{X: shares[1].X, Y: shares[0].Y}
+	if err := Verify(g, moved, commitments, 2); err != ErrInvalidShare {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	other, _, err := Split(g, []byte("test"), 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := Verify(g, other[0], commitments, 2); err != ErrInvalidShare {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestVerify_commitments(t *testing.T) {
+	g := DefaultGroup()
+
+	shares, commitments, err := Split(g, []byte("test"), 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The commitments must match the threshold.
+	if err := Verify(g, shares[0], commitments, 3); err == nil {
+		t.Fatalf("expect error")
+	}
+	if err := Verify(g, shares[0], append(commitments, big.NewInt(1)), 2); err == nil {
+		t.Fatalf("expect error")
+	}
+	if err := Verify(g, shares[0], commitments[:1], 1); err == nil {
+		t.Fatalf("expect error")
+	}
+
+	// P-1 has order 2, it is not in the subgroup of order Q.
+	pm1 := new(big.Int).Sub(g.P, big.NewInt(1))
+	for _, c := range []*big.Int{nil, big.NewInt(0), g.P, pm1} {
+		if err := Verify(g, shares[0], Commitments{commitments[0], c}, 2); err == nil || err == ErrInvalidShare {
+			t.Errorf("%v: unexpected error: %v", c, err)
+		}
+	}
+}
+
+func TestWithRand(t *testing.T) {
+	g := DefaultGroup()
+
+	a, ca, err := Split(g, []byte("test"), 3, 2, WithRand(bytes.NewReader(bytes.Repeat([]byte{7}, 512))))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	b, cb, err := Split(g, []byte("test"), 3, 2, WithRand(bytes.NewReader(bytes.Repeat([]byte{7}, 512))))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if a[0].Y.Cmp(b[0].Y) != 0 || ca[1].Cmp(cb[1]) != 0 {
+		t.Fatalf("bad: %v %v", a, b)
+	}
+
+	if _, _, err := Split(g, []byte("test"), 3, 2, WithRand(bytes.NewReader(nil))); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestCombine_invalid(t *testing.T) {
+	g := DefaultGroup()
+
+	if _, err := Combine(g, nil); err == nil {
+		t.Fatalf("should err")
+	}
+
+	s := Share{X: 1, Y: big.NewInt(1)}
+	if _, err := Combine(g, []Share{s, s}); err == nil {
+		t.Fatalf("should err")
+	}
+}
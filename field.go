@@ -0,0 +1,197 @@
+package shamir
+
+import (
+	"crypto/subtle"
+	"fmt"
+)
+
+// Field is a Galois field GF(2^8), defined by its irreducible reduction
+// polynomial. Shares are only compatible with other implementations using
+// the same field.
+type Field struct {
+	poly uint16
+	// logTable provides the log(X)/log(g) at each index X
+	logTable [256]uint8
+	// expTable provides the anti-log or exponentiation value
+	// for the equivalent index
+	expTable [256]uint8
+}
+
+var (
+	// DefaultField is GF(2^8) with the reduction polynomial
+	// x^8 + x^4 + x^3 + x^2 + 1 (0x11d), as used by libgfshare.
+	DefaultField = &Field{poly: 0x11d, logTable: logTable, expTable: expTable}
+
+	// AESField is GF(2^8) with the reduction polynomial
+	// x^8 + x^4 + x^3 + x + 1 (0x11b), as used by AES, HashiCorp Vault and
+	// SLIP-0039.
+	AESField = mustField(0x11b)
+)
+
+// NewField returns the field GF(2^8) with the given reduction polynomial,
+// including the x^8 term. An error is returned if the polynomial is not
+// irreducible.
+func NewField(poly uint16) (*Field, error) {
+	if poly < 0x100 || poly > 0x1ff {
+		return nil, fmt.Errorf("reduction polynomial must be of degree 8")
+	}
+	if poly == DefaultField.poly {
+		return DefaultField, nil
+	}
+
+	// The powers of a generator run through all non-zero elements. Such a
+	// generator only exists if the polynomial is irreducible, as otherwise
+	// there are zero divisors.
+	f := Field{poly: poly}
+	for g := 2; g < 256; g++ {
+		if f.generate(byte(g)) {
+			return &f, nil
+		}
+	}
+	return nil, fmt.Errorf("reduction polynomial 0x%x is not irreducible", poly)
+}
+
+func mustField(poly uint16) *Field {
+	f, err := NewField(poly)
+	if nil != err {
+		panic(err)
+	}
+	return f
+}
+
+// generate fills the tables using g as generator. It reports false if g does
+// not generate all non-zero elements of the field.
+func (f *Field) generate(g byte) bool {
+	var seen [256]bool
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		if seen[x] {
+			return false
+		}
+		seen[x] = true
+		f.expTable[i] = x
+		f.logTable[x] = byte(i)
+		x = f.slowMul(x, g)
+	}
+	// Can not log(0) so just set it neatly to 0
+	f.logTable[0] = 0
+	return true
+}
+
+// slowMul multiplies two numbers using carry-less multiplication and reduction
+// by the polynomial of the field. It is only used to build the tables.
+func (f *Field) slowMul(a, b byte) byte {
+	var out uint16
+	x := uint16(a)
+	for ; b > 0; b >>= 1 {
+		if b&1 == 1 {
+			out ^= x
+		}
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= f.poly
+		}
+	}
+	return byte(out)
+}
+
+// Poly returns the reduction polynomial of the field, including the x^8 term.
+func (f *Field) Poly() uint16 {
+	return f.poly
+}
+
+// Add combines two numbers in the field.
+// This can also be used for subtraction since it is symmetric.
+func (f *Field) Add(a, b uint8) uint8 {
+	return a ^ b
+}
+
+// Div divides two numbers in the field. It panics if b is zero.
+func (f *Field) Div(a, b uint8) uint8 {
+	if b == 0 {
+		// leaks some timing information but we don't care anyways as this
+		// should never happen, hence the panic
+		panic("divide by zero")
+	}
+
+	var goodVal, zero uint8
+	log_a := f.logTable[a]
+	log_b := f.logTable[b]
+	diff := (int(log_a) - int(log_b)) % 255
+	if diff < 0 {
+		diff += 255
+	}
+
+	ret := f.expTable[diff]
+
+	// Ensure we return zero if a is zero but aren't subject to timing attacks
+	goodVal = ret
+
+	if subtle.ConstantTimeByteEq(a, 0) == 1 {
+		ret = zero
+	} else {
+		ret = goodVal
+	}
+
+	return ret
+}
+
+// Mul multiplies two numbers in the field.
+func (f *Field) Mul(a, b uint8) (out uint8) {
+	var goodVal, zero uint8
+	log_a := f.logTable[a]
+	log_b := f.logTable[b]
+	sum := (int(log_a) + int(log_b)) % 255
+
+	ret := f.expTable[sum]
+
+	// Ensure we return zero if either a or be are zero but aren't subject to
+	// timing attacks
+	goodVal = ret
+
+	if subtle.ConstantTimeByteEq(a, 0) == 1 {
+		ret = zero
+	} else {
+		ret = goodVal
+	}
+
+	if subtle.ConstantTimeByteEq(b, 0) == 1 {
+		ret = zero
+	} else {
+		// This operation does not do anything logically useful. It
+		// only ensures a constant number of assignments to thwart
+		// timing attacks.
+		goodVal = zero
+	}
+
+	return ret
+}
+
+// Interpolate returns the value at x of the polynomial of lowest degree
+// passing through the points given by xs and ys.
+func (f *Field) Interpolate(xs, ys []byte, x byte) byte {
+	var value byte
+	for i, w := range f.lagrange(xs, x) {
+		value = f.Add(value, f.Mul(w, ys[i]))
+	}
+	return value
+}
+
+// lagrange computes the Lagrange basis polynomials for the given x
+// coordinates evaluated at x.
+//
+// The weights only depend on the x coordinates, so they can be computed once
+// per share set and then be applied to every byte of the secret.
+func (f *Field) lagrange(xs []byte, x byte) []byte {
+	weights := make([]byte, len(xs))
+	for i, a := range xs {
+		weight := byte(1)
+		for j, b := range xs {
+			if i != j {
+				weight = f.Mul(weight, f.Div(f.Add(x, b), f.Add(a, b)))
+			}
+		}
+		weights[i] = weight
+	}
+	return weights
+}
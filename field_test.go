@@ -0,0 +1,101 @@
+package shamir
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNewField_default(t *testing.T) {
+	f, err := NewField(0x11d)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if f != DefaultField {
+		t.Fatalf("expect default field")
+	}
+
+	// The generated tables must match the ones from tables.go.
+	var g Field
+	g.poly = 0x11d
+	if !g.generate(2) || g.logTable != logTable || g.expTable != expTable {
+		t.Fatalf("tables do not match")
+	}
+}
+
+func TestNewField_invalid(t *testing.T) {
+	for _, poly := range []uint16{0, 0x1b, 0x200, 0x100, 0x11c, 0x1ff} {
+		if _, err := NewField(poly); err == nil {
+			t.Errorf("0x%x: expect error", poly)
+		}
+	}
+}
+
+func TestAESField(t *testing.T) {
+	// Examples from section 4.2 of FIPS-197.
+	if out := AESField.Mul(0x57, 0x83); out != 0xc1 {
+		t.Fatalf("bad: %x", out)
+	}
+	if out := AESField.Mul(0x57, 0x13); out != 0xfe {
+		t.Fatalf("bad: %x", out)
+	}
+	if out := AESField.Div(0xc1, 0x83); out != 0x57 {
+		t.Fatalf("bad: %x", out)
+	}
+	if AESField.Poly() != 0x11b {
+		t.Fatalf("bad: %x", AESField.Poly())
+	}
+}
+
+func TestField_MulDiv(t *testing.T) {
+	f := AESField
+	for a := 0; a < 256; a++ {
+		for b := 1; b < 256; b++ {
+			if out := f.Mul(byte(a), byte(b)); out != f.slowMul(byte(a), byte(b)) {
+				t.Fatalf("%d * %d: bad: %d", a, b, out)
+			}
+			if out := f.Div(f.Mul(byte(a), byte(b)), byte(b)); out != byte(a) {
+				t.Fatalf("%d / %d: bad: %d", a, b, out)
+			}
+		}
+	}
+}
+
+func TestField_Interpolate(t *testing.T) {
+	p := polynomial{field: AESField, coefficients: []byte{42, 7, 99}}
+	xs := []byte{1, 2, 3}
+	ys := []byte{p.evaluate(1), p.evaluate(2), p.evaluate(3)}
+
+	if out := AESField.Interpolate(xs, ys, 0); out != 42 {
+		t.Fatalf("bad: %v", out)
+	}
+	if out := AESField.Interpolate(xs, ys, 200); out != p.evaluate(200) {
+		t.Fatalf("bad: %v", out)
+	}
+}
+
+func TestWithField(t *testing.T) {
+	secret := []byte("a secret long enough to differ between fields")
+
+	out, err := Split(secret, 3, 2, WithField(AESField), WithCoordinates(1, 2, 3), WithRand(&deterministicReader{}))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	parts := map[byte][]byte{1: out[1], 3: out[3]}
+	recomb, err := Combine(parts, WithField(AESField))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recomb, secret) {
+		t.Fatalf("bad: %v %v", recomb, secret)
+	}
+
+	// Combining in the wrong field does not yield the secret.
+	recomb, err = Combine(parts)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if bytes.Equal(recomb, secret) {
+		t.Fatalf("expect different result")
+	}
+}
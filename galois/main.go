@@ -1,35 +1,49 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"os"
 )
 
 // Helper to (re)create the galois field values in tables.go
+//
+// The reduction polynomial, including the x^8 term, can be selected with the
+// -poly flag. A generator of the field is searched for automatically, which
+// also ensures the polynomial is irreducible. The -prefix flag is prepended
+// to the names of the tables.
 func main() {
+	poly := flag.Uint("poly", 0x11d, "reduction polynomial, e.g. 0x11b")
+	prefix := flag.String("prefix", "", "prefix of the table names")
+	flag.Parse()
+
+	if *poly < 0x100 || *poly > 0x1ff {
+		fmt.Fprintln(os.Stderr, "reduction polynomial must be of degree 8")
+		os.Exit(1)
+	}
+
 	logs := make([]byte, 256)
 	exps := make([]byte, 256)
-	var i byte
-
-	x := 1
-	for i = 0; i < 255; i++ {
-		exps[i] = byte(x)
-		logs[byte(x)] = i
-		x = x << 1
-		if 0 != x&0x100 {
-			// Unset the 8th bit and mix in 0x1d
-			x = x ^ 0x11d
-		}
+	ok := false
+	for g := 2; g < 256 && !ok; g++ {
+		ok = generate(uint(*poly), g, logs, exps)
+	}
+	if !ok {
+		fmt.Fprintf(os.Stderr, "reduction polynomial 0x%x is not irreducible\n", *poly)
+		os.Exit(1)
+	}
 
+	logName, expName := "logTable", "expTable"
+	if "" != *prefix {
+		logName, expName = *prefix+"LogTable", *prefix+"ExpTable"
 	}
-	// Can not log(0) so just set it neatly to 0
-	logs[0] = 0
 
 	fmt.Println(`package shamir
 
 var (`)
 
-	fmt.Println("\t// logTable provides the log(X)/log(g) at each index X")
-	fmt.Print("\tlogTable = [256]uint8{\n\t\t")
+	fmt.Println("\t// " + logName + " provides the log(X)/log(g) at each index X")
+	fmt.Print("\t" + logName + " = [256]uint8{\n\t\t")
 	for i, log := range logs {
 		fmt.Printf("0x%02x", log)
 		if 255 == i {
@@ -43,9 +57,9 @@ var (`)
 
 	fmt.Print("\t}\n\n")
 
-	fmt.Println("\t// expTable provides the anti-log or exponentiation value")
+	fmt.Println("\t// " + expName + " provides the anti-log or exponentiation value")
 	fmt.Println("\t// for the equivalent index")
-	fmt.Printf("\texpTable = [256]uint8{\n\t\t")
+	fmt.Printf("\t" + expName + " = [256]uint8{\n\t\t")
 	for i, exp := range exps {
 		fmt.Printf("0x%02x", exp)
 		if 255 == i {
@@ -58,3 +72,42 @@ var (`)
 	}
 	fmt.Println("\t}\n)")
 }
+
+// generate fills the tables using the powers of g. It reports false if g does
+// not generate all non-zero elements of the field.
+func generate(poly uint, g int, logs, exps []byte) bool {
+	seen := make([]bool, 256)
+	var i byte
+
+	x := 1
+	for i = 0; i < 255; i++ {
+		if seen[x] {
+			return false
+		}
+		seen[x] = true
+		exps[i] = byte(x)
+		logs[byte(x)] = i
+		x = mul(poly, x, g)
+	}
+	// Can not log(0) so just set it neatly to 0
+	logs[0] = 0
+
+	return true
+}
+
+// mul multiplies a and b using carry-less multiplication, reducing the result
+// by poly.
+func mul(poly uint, a, b int) int {
+	out := 0
+	for ; b > 0; b >>= 1 {
+		if 0 != b&1 {
+			out ^= a
+		}
+		a <<= 1
+		if 0 != a&0x100 {
+			// Unset the 8th bit and mix in the lower bits of the polynomial
+			a ^= int(poly)
+		}
+	}
+	return out
+}
@@ -0,0 +1,82 @@
+package hierarchical
+
+import "github.com/corvus-ch/shamir"
+
+// row returns the factors by which the share of order k at x depends on each of
+// the n coefficients of the polynomial.
+func row(f *shamir.Field, k int, x byte, n int) []byte {
+	r := make([]byte, n)
+	p := byte(1)
+	for j := k; j < n; j++ {
+		r[j] = p
+		p = f.Mul(p, x)
+	}
+	return r
+}
+
+// weights returns the weights of the rows summing up to the unit vector of the
+// first coefficient, that is the weights to compute the secret from the
+// shares. It returns false if the rows do not determine the secret.
+func weights(f *shamir.Field, rows [][]byte) ([]byte, bool) {
+	if len(rows) == 0 {
+		return nil, false
+	}
+	n := len(rows[0])
+
+	// Solve the transposed system rowsᵀ·w = e₀ by Gauss-Jordan elimination on
+	// the augmented matrix.
+	m := make([][]byte, n)
+	for j := range m {
+		m[j] = make([]byte, len(rows)+1)
+		for i, r := range rows {
+			m[j][i] = r[j]
+		}
+	}
+	m[0][len(rows)] = 1
+
+	pivots := make([]int, 0, n)
+	rank := 0
+	for col := 0; col < len(rows) && rank < n; col++ {
+		p := -1
+		for j := rank; j < n; j++ {
+			if m[j][col] != 0 {
+				p = j
+				break
+			}
+		}
+		if p < 0 {
+			continue
+		}
+		m[rank], m[p] = m[p], m[rank]
+		inv := f.Div(1, m[rank][col])
+		for i := range m[rank] {
+			m[rank][i] = f.Mul(m[rank][i], inv)
+		}
+		for j := range m {
+			if j == rank || m[j][col] == 0 {
+				continue
+			}
+			factor := m[j][col]
+			for i := range m[j] {
+				m[j][i] = f.Add(m[j][i], f.Mul(factor, m[rank][i]))
+			}
+		}
+		pivots = append(pivots, col)
+		rank++
+	}
+
+	// The system is inconsistent if a zero row remains with a non-zero
+	// right hand side.
+	for j := rank; j < n; j++ {
+		if m[j][len(rows)] != 0 {
+			return nil, false
+		}
+	}
+
+	// Any solution will do, the free variables are left at zero.
+	w := make([]byte, len(rows))
+	for j, col := range pivots {
+		w[col] = m[j][len(rows)]
+	}
+	return w, true
+}
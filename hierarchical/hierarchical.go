@@ -0,0 +1,430 @@
+// Package hierarchical implements hierarchical threshold secret sharing as
+// described by Tassa, which expresses policies such as "two shares, at least
+// one of which from the security team".
+//
+// The shareholders are partitioned into ordered levels, the first being the
+// most privileged one. Each level has a cumulative threshold: a set of shares
+// is authorised to reconstruct the secret if, for every level, it holds at
+// least as many shares of that or more privileged levels as the threshold of
+// the level. The threshold of the last level is the total number of shares
+// required.
+//
+// Like Split of the parent package, the secret bytes are the constant terms of
+// random polynomials over GF(2^8). Tassa gives the members of a level the k-th
+// derivative of the polynomial, k being the threshold of the previous level,
+// so these shares do not depend on the first k coefficients. In
+// characteristic 2 however, derivatives, even Hasse derivatives, lose all
+// terms with an even binomial coefficient, which would leave the members of a
+// level with the very same share. Instead, members of a level receive the
+// value of
+//
+//	f_k(x) = a_k + a_{k+1}·x + a_{k+2}·x² + …
+//
+// which does not depend on the first k coefficients either. The secret is
+// reconstructed by solving the resulting Birkhoff interpolation problem.
+//
+// As with Tassa's scheme over small fields, whether a set of shares is able to
+// reconstruct the secret depends on the x coordinates. Split therefore checks
+// that every authorised set reconstructs the secret and that no other set
+// learns anything about it, picking other x coordinates if necessary. Each of
+// these sets fails with a chance of about one in 256, so in a field as small as
+// GF(2^8) only policies of a few levels and a dozen or so shareholders are
+// feasible. For larger ones Split fails to find suitable x coordinates.
+package hierarchical
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/corvus-ch/shamir"
+)
+
+// ErrUnauthorized is returned by Combine if the shares are not authorised to
+// reconstruct the secret.
+var ErrUnauthorized = errors.New("shares are not authorised to reconstruct the secret")
+
+const (
+	// maxAttempts limits the number of times Split picks new x coordinates.
+	maxAttempts = 64
+	// maxChecks limits the number of sets of shares Split checks.
+	maxChecks = 1 << 16
+)
+
+// Option configures the optional behaviour of Split.
+type Option func(*config)
+
+type config struct {
+	rand  io.Reader
+	field *shamir.Field
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{rand: rand.Reader, field: shamir.DefaultField}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithRand sets the source of randomness used to pick the x coordinates and
+// the polynomial coefficients. It defaults to crypto/rand.Reader.
+func WithRand(r io.Reader) Option {
+	return func(c *config) {
+		c.rand = r
+	}
+}
+
+// WithField sets the field the shares are computed in. It defaults to
+// shamir.DefaultField. Shares must be combined using the same field they were
+// split with.
+func WithField(f *shamir.Field) Option {
+	return func(c *config) {
+		c.field = f
+	}
+}
+
+// Level describes a level of shareholders.
+type Level struct {
+	// Threshold is the number of shares of this or more privileged levels
+	// required to reconstruct the secret.
+	Threshold int
+	// Count is the number of shareholders of this level.
+	Count int
+}
+
+// Share is a share of a hierarchical split.
+type Share struct {
+	// Level is the index of the level of the share.
+	Level int
+	// Order is the number of polynomial coefficients the share does not
+	// depend on, that is the threshold of the previous level.
+	Order int
+	// Threshold is the total number of shares required to reconstruct the
+	// secret.
+	Threshold int
+	X         byte
+	Value     []byte
+}
+
+// holder describes a shareholder by the order of its share and its x
+// coordinate.
+type holder struct {
+	order int
+	x     byte
+}
+
+// Split splits the secret among the shareholders of the levels, returning the
+// shares of each level in the order of the levels.
+//
+// The thresholds must be increasing, the threshold of the last level at least
+// 2, and the levels up to each one must have at least as many shareholders as
+// its threshold. There must be no more than 255 shareholders.
+func Split(secret []byte, levels []Level, opts ...Option) ([][]*Share, error) {
+	if err := checkLevels(levels); nil != err {
+		return nil, err
+	}
+	cfg := newConfig(opts)
+	f := cfg.field
+
+	var holders []holder
+	for i, l := range levels {
+		order := 0
+		if i > 0 {
+			order = levels[i-1].Threshold
+		}
+		for j := 0; j < l.Count; j++ {
+			holders = append(holders, holder{order: order})
+		}
+	}
+	if err := pickCoordinates(cfg, levels, holders); nil != err {
+		return nil, err
+	}
+
+	t := levels[len(levels)-1].Threshold
+	coeffs := make([]byte, t)
+	defer zero(coeffs)
+	values := make([][]byte, len(holders))
+	for i := range values {
+		values[i] = make([]byte, len(secret))
+	}
+	for i, s := range secret {
+		coeffs[0] = s
+		if _, err := io.ReadFull(cfg.rand, coeffs[1:]); nil != err {
+			return nil, fmt.Errorf("failed to generate polynomial: %v", err)
+		}
+		for j, h := range holders {
+			values[j][i] = evaluate(f, coeffs[h.order:], h.x)
+		}
+	}
+
+	out := make([][]*Share, len(levels))
+	k := 0
+	for i, l := range levels {
+		for j := 0; j < l.Count; j++ {
+			out[i] = append(out[i], &Share{
+				Level:     i,
+				Order:     holders[k].order,
+				Threshold: t,
+				X:         holders[k].x,
+				Value:     values[k],
+			})
+			k++
+		}
+	}
+	return out, nil
+}
+
+func checkLevels(levels []Level) error {
+	if len(levels) == 0 {
+		return fmt.Errorf("at least one level is required")
+	}
+	prev, n := 0, 0
+	for i, l := range levels {
+		if l.Count < 1 {
+			return fmt.Errorf("level %d must have at least one shareholder", i)
+		}
+		if l.Threshold <= prev {
+			return fmt.Errorf("threshold of level %d must exceed the one of the previous level", i)
+		}
+		n += l.Count
+		if l.Threshold > n {
+			return fmt.Errorf("threshold of level %d exceeds the number of shareholders up to it", i)
+		}
+		prev = l.Threshold
+	}
+	if prev < 2 {
+		return fmt.Errorf("threshold must be at least 2")
+	}
+	if n > 255 {
+		return fmt.Errorf("number of shareholders cannot exceed 255")
+	}
+	return nil
+}
+
+// pickCoordinates assigns random x coordinates to the holders for which the
+// levels work as intended.
+func pickCoordinates(cfg *config, levels []Level, holders []holder) error {
+	if err := checkCount(levels, len(holders)); nil != err {
+		return err
+	}
+
+	buf := make([]byte, 1)
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		var used [256]bool
+		for i := range holders {
+			for {
+				if _, err := io.ReadFull(cfg.rand, buf); nil != err {
+					return fmt.Errorf("failed to pick x coordinates: %v", err)
+				}
+				if x := buf[0]; x != 0 && !used[x] {
+					used[x] = true
+					holders[i].x = x
+					break
+				}
+			}
+		}
+		if verify(cfg.field, levels, holders) {
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to find x coordinates for the levels")
+}
+
+// checkCount ensures the number of sets of shares verify checks is within
+// bounds.
+func checkCount(levels []Level, n int) error {
+	total := binomial(n, levels[len(levels)-1].Threshold)
+	m := 0
+	for _, l := range levels {
+		m += l.Count
+		total += binomial(m, min(l.Threshold-1, m))
+	}
+	if total > maxChecks {
+		return fmt.Errorf("too many shareholders to verify the levels")
+	}
+	return nil
+}
+
+// verify reports whether the sets of shares authorised by the levels, and only
+// those, determine the secret for the x coordinates of the holders.
+func verify(f *shamir.Field, levels []Level, holders []holder) bool {
+	t := levels[len(levels)-1].Threshold
+
+	// Every minimal authorised set, and thus every authorised set, must
+	// determine the secret. The minimal authorised sets are the authorised
+	// ones of exactly t shares.
+	ok := true
+	combinations(len(holders), t, func(idx []int) bool {
+		if !authorised(levels, idx) {
+			return true
+		}
+		rows := make([][]byte, len(idx))
+		for i, j := range idx {
+			rows[i] = row(f, holders[j].order, holders[j].x, t)
+		}
+		_, ok = weights(f, rows)
+		return ok
+	})
+	if !ok {
+		return false
+	}
+
+	// A set falls short of the threshold k of some level, holding at most
+	// k-1 shares of that and more privileged levels. Any polynomial of
+	// degree less than k vanishes on the shares of the less privileged
+	// levels. If the secret is not determined by those k-1 shares as if the
+	// polynomial had degree k-1, one such polynomial with a non-zero constant
+	// term vanishes on all of the shares, so the set learns nothing.
+	m := 0
+	for _, l := range levels {
+		m += l.Count
+		k := l.Threshold
+		combinations(m, min(k-1, m), func(idx []int) bool {
+			rows := make([][]byte, len(idx))
+			for i, j := range idx {
+				rows[i] = row(f, holders[j].order, holders[j].x, k)
+			}
+			_, determined := weights(f, rows)
+			ok = !determined
+			return ok
+		})
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// authorised reports whether the holders at the ascending indices idx satisfy
+// the thresholds of all levels.
+func authorised(levels []Level, idx []int) bool {
+	n, m := 0, 0
+	for _, l := range levels {
+		m += l.Count
+		for n < len(idx) && idx[n] < m {
+			n++
+		}
+		if n < l.Threshold {
+			return false
+		}
+	}
+	return true
+}
+
+// Combine reconstructs the secret from the shares. It returns ErrUnauthorized
+// if the shares do not satisfy the thresholds of the levels.
+func Combine(shares []*Share, opts ...Option) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, ErrUnauthorized
+	}
+	f := newConfig(opts).field
+
+	t := shares[0].Threshold
+	var used [256]bool
+	rows := make([][]byte, len(shares))
+	for i, s := range shares {
+		if s.Threshold != t {
+			return nil, fmt.Errorf("shares must have the same threshold")
+		}
+		if s.Order < 0 || s.Order >= t {
+			return nil, fmt.Errorf("order of share %d must be less than the threshold", s.X)
+		}
+		if len(s.Value) != len(shares[0].Value) {
+			return nil, fmt.Errorf("all shares must be the same length")
+		}
+		if s.X == 0 {
+			return nil, fmt.Errorf("x coordinate cannot be zero")
+		}
+		if used[s.X] {
+			return nil, fmt.Errorf("duplicate share for x coordinate %d", s.X)
+		}
+		used[s.X] = true
+		rows[i] = row(f, s.Order, s.X, t)
+	}
+
+	w, ok := weights(f, rows)
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+
+	secret := make([]byte, len(shares[0].Value))
+	for j, s := range shares {
+		if w[j] == 0 {
+			continue
+		}
+		for i, y := range s.Value {
+			secret[i] = f.Add(secret[i], f.Mul(w[j], y))
+		}
+	}
+	return secret, nil
+}
+
+// evaluate returns the value at x of the polynomial with the given
+// coefficients, lowest degree first.
+func evaluate(f *shamir.Field, coeffs []byte, x byte) byte {
+	var out byte
+	for i := len(coeffs) - 1; i >= 0; i-- {
+		out = f.Add(f.Mul(out, x), coeffs[i])
+	}
+	return out
+}
+
+// combinations calls fn with the ascending indices of every subset of k out of
+// n elements until fn returns false.
+func combinations(n, k int, fn func(idx []int) bool) {
+	if k < 0 || k > n {
+		return
+	}
+	idx := make([]int, k)
+	for i := range idx {
+		idx[i] = i
+	}
+	for {
+		if !fn(idx) {
+			return
+		}
+		i := k - 1
+		for i >= 0 && idx[i] == n-k+i {
+			i--
+		}
+		if i < 0 {
+			return
+		}
+		idx[i]++
+		for j := i + 1; j < k; j++ {
+			idx[j] = idx[j-1] + 1
+		}
+	}
+}
+
+// binomial returns n choose k, saturating at maxChecks+1.
+func binomial(n, k int) int {
+	if k < 0 || k > n {
+		return 0
+	}
+	k = min(k, n-k)
+	out := 1
+	for i := 0; i < k; i++ {
+		out = out * (n - i) / (i + 1)
+		if out > maxChecks {
+			return maxChecks + 1
+		}
+	}
+	return out
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// zero overwrites b with zeros.
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,169 @@
+package hierarchical
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/corvus-ch/shamir"
+)
+
+// flatten returns the shares of all levels in the order of the levels.
+func flatten(shares [][]*Share) []*Share {
+	var out []*Share
+	for _, l := range shares {
+		out = append(out, l...)
+	}
+	return out
+}
+
+// testAllSubsets combines every subset of the shares and checks the secret is
+// reconstructed if, and only if, the subset is authorised.
+func testAllSubsets(t *testing.T, levels []Level, shares []*Share, secret []byte, opts ...Option) {
+	t.Helper()
+	for mask := 1; mask < 1<<uint(len(shares)); mask++ {
+		var subset []*Share
+		var idx []int
+		for i, s := range shares {
+			if mask>>uint(i)&1 == 1 {
+				subset = append(subset, s)
+				idx = append(idx, i)
+			}
+		}
+
+		out, err := Combine(subset, opts...)
+		if authorised(levels, idx) {
+			if err != nil {
+				t.Fatalf("%b: err: %v", mask, err)
+			}
+			if !bytes.Equal(out, secret) {
+				t.Fatalf("%b: bad: %v", mask, out)
+			}
+		} else if err != ErrUnauthorized {
+			t.Fatalf("%b: bad: %v", mask, err)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	secret := []byte("test")
+
+	// Two shares, at least one of which from the security team.
+	levels := []Level{{Threshold: 1, Count: 3}, {Threshold: 2, Count: 5}}
+	shares, err := Split(secret, levels)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(shares) != 2 || len(shares[0]) != 3 || len(shares[1]) != 5 {
+		t.Fatalf("bad: %v", shares)
+	}
+	for _, s := range shares[1] {
+		if s.Level != 1 || s.Order != 1 || s.Threshold != 2 {
+			t.Fatalf("bad: %v", s)
+		}
+	}
+
+	testAllSubsets(t, levels, flatten(shares), secret)
+}
+
+func TestSplit_threeLevels(t *testing.T) {
+	secret := []byte("test")
+
+	levels := []Level{{Threshold: 2, Count: 3}, {Threshold: 3, Count: 3}, {Threshold: 5, Count: 4}}
+	shares, err := Split(secret, levels, WithField(shamir.AESField))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	testAllSubsets(t, levels, flatten(shares), secret, WithField(shamir.AESField))
+}
+
+func TestSplit_flat(t *testing.T) {
+	secret := []byte("test")
+
+	levels := []Level{{Threshold: 3, Count: 5}}
+	shares, err := Split(secret, levels)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	testAllSubsets(t, levels, flatten(shares), secret)
+}
+
+func TestSplit_invalid(t *testing.T) {
+	for _, levels := range [][]Level{
+		nil,
+		{{Threshold: 1, Count: 3}},
+		{{Threshold: 2, Count: 0}},
+		{{Threshold: 4, Count: 3}},
+		{{Threshold: 2, Count: 3}, {Threshold: 2, Count: 3}},
+		{{Threshold: 1, Count: 1}, {Threshold: 5, Count: 3}},
+		{{Threshold: 2, Count: 200}, {Threshold: 3, Count: 56}},
+		{{Threshold: 20, Count: 40}, {Threshold: 30, Count: 20}},
+	} {
+		if _, err := Split([]byte("test"), levels); err == nil {
+			t.Errorf("%v: expect error", levels)
+		}
+	}
+}
+
+func TestCombine_invalid(t *testing.T) {
+	shares, err := Split([]byte("test"), []Level{{Threshold: 1, Count: 1}, {Threshold: 2, Count: 2}})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	a, b := shares[0][0], shares[1][0]
+
+	if _, err := Combine(nil); err != ErrUnauthorized {
+		t.Fatalf("bad: %v", err)
+	}
+	if _, err := Combine([]*Share{a, a}); err == nil {
+		t.Fatalf("expect error")
+	}
+	for _, s := range []Share{
+		{Order: 1, Threshold: 3, X: b.X, Value: b.Value},
+		{Order: 2, Threshold: 2, X: b.X, Value: b.Value},
+		{Order: 1, Threshold: 2, X: b.X, Value: b.Value[1:]},
+		{Order: 1, Threshold: 2, X: 0, Value: b.Value},
+	} {
+		s := s
+		if _, err := Combine([]*Share{a, &s}); err == nil {
+			t.Errorf("%v: expect error", s)
+		}
+	}
+}
+
+func TestWeights(t *testing.T) {
+	f := shamir.DefaultField
+
+	// Plain Lagrange interpolation at zero.
+	rows := [][]byte{row(f, 0, 1, 2), row(f, 0, 2, 2)}
+	w, ok := weights(f, rows)
+	if !ok {
+		t.Fatal("expect solution")
+	}
+	if exp := []byte{f.Div(2, 3), f.Div(1, 3)}; !bytes.Equal(w, exp) {
+		t.Fatalf("bad: %v", w)
+	}
+
+	// Shares of order one do not depend on the secret.
+	if _, ok := weights(f, [][]byte{row(f, 1, 1, 2), row(f, 1, 2, 2)}); ok {
+		t.Fatal("expect no solution")
+	}
+}
+
+func TestCombinations(t *testing.T) {
+	var out [][]int
+	combinations(4, 2, func(idx []int) bool {
+		out = append(out, append([]int(nil), idx...))
+		return true
+	})
+	if len(out) != 6 || out[0][0] != 0 || out[0][1] != 1 || out[5][0] != 2 || out[5][1] != 3 {
+		t.Fatalf("bad: %v", out)
+	}
+
+	for _, tc := range [][3]int{{4, 2, 6}, {10, 0, 1}, {10, 10, 1}, {10, 3, 120}, {3, 4, 0}, {200, 100, maxChecks + 1}} {
+		if out := binomial(tc[0], tc[1]); out != tc[2] {
+			t.Errorf("%v: bad: %d", tc, out)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Package getopt parses command line options the way glibc getopt does, which
+// is what the libgfshare tools use. Unlike the flag package, it accepts option
+// arguments attached to the option (-n3), clustered options (-hv) and options
+// following non-option arguments (file -n 3).
+package getopt
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Option is a parsed option with its argument, if it takes one.
+type Option struct {
+	Name byte
+	Arg  string
+}
+
+// Parse parses args according to optstring, which lists the option
+// characters, each followed by a colon if the option takes an argument.
+//
+// Like glibc, Parse permutes the arguments: options are recognised anywhere
+// until "--" and the non-option arguments are returned in their order along
+// with the options. If optstring starts with "+" or the environment variable
+// POSIXLY_CORRECT is set, parsing stops at the first non-option argument as
+// POSIX requires.
+func Parse(args []string, optstring string) ([]Option, []string, error) {
+	posix := os.Getenv("POSIXLY_CORRECT") != ""
+	if strings.HasPrefix(optstring, "+") {
+		optstring = optstring[1:]
+		posix = true
+	}
+
+	var opts []Option
+	var rest []string
+	for len(args) > 0 {
+		arg := args[0]
+		if arg == "--" {
+			return opts, append(rest, args[1:]...), nil
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			if posix {
+				break
+			}
+			rest = append(rest, arg)
+			args = args[1:]
+			continue
+		}
+		args = args[1:]
+
+		for i := 1; i < len(arg); i++ {
+			c := arg[i]
+			j := strings.IndexByte(optstring, c)
+			if c == ':' || j < 0 {
+				return nil, nil, fmt.Errorf("invalid option -- '%c'", c)
+			}
+			if j+1 >= len(optstring) || optstring[j+1] != ':' {
+				opts = append(opts, Option{Name: c})
+				continue
+			}
+
+			// The argument is either the rest of this argument or
+			// the next one.
+			if i+1 < len(arg) {
+				opts = append(opts, Option{Name: c, Arg: arg[i+1:]})
+			} else if len(args) > 0 {
+				opts = append(opts, Option{Name: c, Arg: args[0]})
+				args = args[1:]
+			} else {
+				return nil, nil, fmt.Errorf("option requires an argument -- '%c'", c)
+			}
+			break
+		}
+	}
+	return opts, append(rest, args...), nil
+}
@@ -0,0 +1,64 @@
+package getopt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		args []string
+		opts []Option
+		rest []string
+	}{
+		{nil, nil, nil},
+		{[]string{"file"}, nil, []string{"file"}},
+		{[]string{"-n", "2", "-m3", "file", "--", "-x"}, []Option{{'n', "2"}, {'m', "3"}}, []string{"file", "-x"}},
+		{[]string{"in", "-n", "2", "out", "-m3"}, []Option{{'n', "2"}, {'m', "3"}}, []string{"in", "out"}},
+		{[]string{"in", "-", "-n2", "--", "out", "-m3"}, []Option{{'n', "2"}}, []string{"in", "-", "out", "-m3"}},
+		{[]string{"-hv", "--", "-n"}, []Option{{'h', ""}, {'v', ""}}, []string{"-n"}},
+		{[]string{"-hn2", "-"}, []Option{{'h', ""}, {'n', "2"}}, []string{"-"}},
+	}
+	for _, test := range tests {
+		opts, rest, err := Parse(test.args, "hvn:m:")
+		if err != nil {
+			t.Fatalf("%v: %v", test.args, err)
+		}
+		if !reflect.DeepEqual(opts, test.opts) {
+			t.Errorf("%v: unexpected options %v", test.args, opts)
+		}
+		if len(rest) != len(test.rest) || (len(rest) > 0 && !reflect.DeepEqual(rest, test.rest)) {
+			t.Errorf("%v: unexpected arguments %v", test.args, rest)
+		}
+	}
+}
+
+func TestParse_posix(t *testing.T) {
+	args := []string{"-n", "2", "in", "-m3"}
+	exp := []Option{{'n', "2"}}
+
+	opts, rest, err := Parse(args, "+hvn:m:")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(opts, exp) || !reflect.DeepEqual(rest, []string{"in", "-m3"}) {
+		t.Fatalf("bad: %v %v", opts, rest)
+	}
+
+	t.Setenv("POSIXLY_CORRECT", "1")
+	opts, rest, err = Parse(args, "hvn:m:")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(opts, exp) || !reflect.DeepEqual(rest, []string{"in", "-m3"}) {
+		t.Fatalf("bad: %v %v", opts, rest)
+	}
+}
+
+func TestParse_invalid(t *testing.T) {
+	for _, args := range [][]string{{"-x"}, {"-n"}, {"-h:"}, {"file", "-x"}} {
+		if _, _, err := Parse(args, "hvn:m:"); err == nil {
+			t.Errorf("%v: expect error", args)
+		}
+	}
+}
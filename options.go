@@ -0,0 +1,66 @@
+package shamir
+
+import (
+	"crypto/rand"
+	"io"
+)
+
+// Option configures the optional behaviour of the split and combine
+// functions.
+type Option func(*config)
+
+type config struct {
+	rand  io.Reader
+	xs    []byte
+	field *Field
+	setID *SetID
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{rand: rand.Reader, field: DefaultField}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithRand sets the source of randomness used to pick the x coordinates and
+// the polynomial coefficients. It defaults to crypto/rand.Reader.
+//
+// The security of the shares depends entirely on the quality of this source.
+// Use anything other than a cryptographically secure random number generator
+// only for testing.
+func WithRand(r io.Reader) Option {
+	return func(c *config) {
+		c.rand = r
+	}
+}
+
+// WithCoordinates sets the x coordinates of the shares instead of picking them
+// at random. Exactly one coordinate per share must be given, and all of them
+// must be unique and non-zero. The coordinates are copied, and an empty list
+// is an error rather than a request for random coordinates.
+func WithCoordinates(xs ...byte) Option {
+	xs = append([]byte{}, xs...)
+	return func(c *config) {
+		c.xs = xs
+	}
+}
+
+// WithField sets the field the shares are computed in. It defaults to
+// DefaultField. Shares must be combined using the same field they were split
+// with.
+func WithField(f *Field) Option {
+	return func(c *config) {
+		c.field = f
+	}
+}
+
+// WithSetID sets the set identifier of the shares instead of picking it at
+// random. Share.SubShare and Share.RefreshUpdate require it, as all holders
+// must agree on the identifier of the new share set.
+func WithSetID(id SetID) Option {
+	return func(c *config) {
+		c.setID = &id
+	}
+}
@@ -0,0 +1,166 @@
+package shamir
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// deterministicReader produces a reproducible stream of bytes by hashing an
+// incrementing counter. It must only be used for testing.
+type deterministicReader struct {
+	counter uint64
+	buf     []byte
+}
+
+func (r *deterministicReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(r.buf) == 0 {
+			var c [8]byte
+			binary.BigEndian.PutUint64(c[:], r.counter)
+			r.counter++
+			sum := sha256.Sum256(c[:])
+			r.buf = sum[:]
+		}
+		m := copy(p[n:], r.buf)
+		r.buf = r.buf[m:]
+		n += m
+	}
+	return n, nil
+}
+
+func TestWithRand(t *testing.T) {
+	secret := []byte("test")
+
+	a, err := Split(secret, 5, 3, WithRand(&deterministicReader{}))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	b, err := Split(secret, 5, 3, WithRand(&deterministicReader{}))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if len(a) != len(b) {
+		t.Fatalf("bad: %v %v", a, b)
+	}
+	for x, part := range a {
+		if !bytes.Equal(part, b[x]) {
+			t.Fatalf("bad: %v %v", a, b)
+		}
+	}
+
+	recomb, err := Combine(a)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recomb, secret) {
+		t.Fatalf("bad: %v %v", recomb, secret)
+	}
+}
+
+func TestWithRand_error(t *testing.T) {
+	if _, err := Split([]byte("test"), 5, 3, WithRand(bytes.NewReader(nil))); err == nil {
+		t.Fatalf("expect error")
+	}
+
+	// Enough randomness for the x coordinates but not for the coefficients.
+	if _, err := Split([]byte("test"), 2, 2, WithRand(bytes.NewReader([]byte{1, 2}))); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func ExampleWithRand() {
+	// A seeded math/rand source makes the shares reproducible. It is not
+	// cryptographically secure and must never be used for real secrets.
+	r := rand.New(rand.NewSource(1))
+
+	parts, err := Split([]byte("Hello world"), 3, 2, WithRand(r), WithCoordinates(1, 2, 3))
+	if err != nil {
+		fmt.Println(err)
+	}
+	for _, x := range []byte{1, 2, 3} {
+		fmt.Println(x, parts[x])
+	}
+	// Output:
+	// 1 [26 152 144 107 78 162 18 32 100 83 59]
+	// 2 [236 130 137 98 45 57 189 241 94 18 218]
+	// 3 [190 127 117 101 12 187 216 190 72 45 133]
+}
+
+func TestWithCoordinates(t *testing.T) {
+	secret := []byte("test")
+
+	out, err := Split(secret, 5, 3, WithCoordinates(1, 2, 3, 4, 5))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(out) != 5 {
+		t.Fatalf("bad: %v", out)
+	}
+	for x := byte(1); x <= 5; x++ {
+		if _, ok := out[x]; !ok {
+			t.Fatalf("missing share %d: %v", x, out)
+		}
+	}
+
+	recomb, err := Combine(map[byte][]byte{1: out[1], 3: out[3], 5: out[5]})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recomb, secret) {
+		t.Fatalf("bad: %v %v", recomb, secret)
+	}
+}
+
+func TestWithCoordinates_invalid(t *testing.T) {
+	secret := []byte("test")
+
+	for _, xs := range [][]byte{
+		{},
+		{1, 2},
+		{1, 2, 3, 4},
+		{0, 1, 2},
+		{1, 2, 1},
+	} {
+		if _, err := Split(secret, 3, 2, WithCoordinates(xs...)); err == nil {
+			t.Errorf("expect error for %v", xs)
+		}
+	}
+
+	if _, err := Split(secret, 3, 2, WithCoordinates()); err == nil {
+		t.Errorf("expect error for no coordinates")
+	}
+}
+
+func TestWithCoordinates_copy(t *testing.T) {
+	xs := []byte{1, 2, 3}
+	opt := WithCoordinates(xs...)
+	xs[0] = 4
+
+	out, err := Split([]byte("test"), 3, 2, opt)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, ok := out[1]; !ok {
+		t.Fatalf("bad: %v", out)
+	}
+}
+
+func TestWithSetID(t *testing.T) {
+	id := SetID{1, 2, 3}
+
+	shares, err := SplitShares([]byte("test"), 3, 2, WithSetID(id))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, s := range shares {
+		if s.SetID != id || s.Salt != id {
+			t.Fatalf("bad: %v", s)
+		}
+	}
+}
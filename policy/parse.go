@@ -0,0 +1,314 @@
+package policy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// kind is the kind of a node of a policy.
+type kind int
+
+const (
+	leaf kind = iota
+	and
+	or
+	threshold
+)
+
+// node is a node of the syntax tree of a policy.
+type node struct {
+	kind kind
+	// name is the participant of a leaf.
+	name string
+	// k is the number of children required by a threshold gate.
+	k        int
+	children []*node
+	// index numbers the leaves in the order they appear in the policy.
+	index int
+}
+
+// maxChildren is the largest number of children of a gate, limited by the
+// number of x coordinates available to threshold gates.
+const maxChildren = 255
+
+// Operator precedences, used to decide where parentheses are needed.
+const (
+	precOr = iota + 1
+	precAnd
+	precAtom
+)
+
+func (n *node) prec() int {
+	switch n.kind {
+	case or:
+		return precOr
+	case and:
+		return precAnd
+	default:
+		return precAtom
+	}
+}
+
+// format writes the policy in its canonical form, parenthesising n if its
+// precedence is below prec.
+func (n *node) format(sb *strings.Builder, prec int) {
+	switch n.kind {
+	case leaf:
+		sb.WriteString(n.name)
+		return
+	case threshold:
+		fmt.Fprintf(sb, "%dof(", n.k)
+		for i, c := range n.children {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			c.format(sb, precOr)
+		}
+		sb.WriteString(")")
+		return
+	}
+
+	op := " AND "
+	if n.kind == or {
+		op = " OR "
+	}
+	if n.prec() < prec {
+		sb.WriteString("(")
+	}
+	for i, c := range n.children {
+		if i > 0 {
+			sb.WriteString(op)
+		}
+		// Nested gates of the same kind keep their parentheses.
+		c.format(sb, n.prec()+1)
+	}
+	if n.prec() < prec {
+		sb.WriteString(")")
+	}
+}
+
+func (n *node) String() string {
+	var sb strings.Builder
+	n.format(&sb, precOr)
+	return sb.String()
+}
+
+// token kinds of the policy language.
+const (
+	tokEOF = iota
+	tokName
+	tokAnd
+	tokOr
+	tokOf
+	tokOpen
+	tokClose
+	tokComma
+)
+
+type token struct {
+	kind int
+	text string
+	// k is the number preceding "of".
+	k   int
+	pos int
+}
+
+// lex splits a policy into tokens.
+func lex(s string) ([]token, error) {
+	var out []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			out = append(out, token{kind: tokOpen, text: "(", pos: i})
+			i++
+		case c == ')':
+			out = append(out, token{kind: tokClose, text: ")", pos: i})
+			i++
+		case c == ',':
+			out = append(out, token{kind: tokComma, text: ",", pos: i})
+			i++
+		case isDigit(c):
+			start := i
+			for i < len(s) && isDigit(s[i]) {
+				i++
+			}
+			k, err := strconv.Atoi(s[start:i])
+			if nil != err {
+				return nil, fmt.Errorf("invalid number at %d", start)
+			}
+			for i < len(s) && s[i] == ' ' {
+				i++
+			}
+			if i+2 > len(s) || !strings.EqualFold(s[i:i+2], "of") || (i+2 < len(s) && isNameChar(s[i+2])) {
+				return nil, fmt.Errorf("expected \"of\" after number at %d", start)
+			}
+			i += 2
+			out = append(out, token{kind: tokOf, text: s[start:i], k: k, pos: start})
+		case isNameStart(c):
+			start := i
+			for i < len(s) && isNameChar(s[i]) {
+				i++
+			}
+			t := token{kind: tokName, text: s[start:i], pos: start}
+			switch strings.ToUpper(t.text) {
+			case "AND":
+				t.kind = tokAnd
+			case "OR":
+				t.kind = tokOr
+			}
+			out = append(out, t)
+		default:
+			return nil, fmt.Errorf("unexpected character %q at %d", c, i)
+		}
+	}
+	return append(out, token{kind: tokEOF, pos: len(s)}), nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isNameStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || isDigit(c) || c == '-' || c == '.' || c == '@'
+}
+
+// parser is a recursive descent parser of the grammar
+//
+//	expr   = term { "OR" term }
+//	term   = factor { "AND" factor }
+//	factor = name | "(" expr ")" | number "of" "(" expr { "," expr } ")"
+type parser struct {
+	tokens []token
+	pos    int
+	leaves int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) expect(kind int, what string) error {
+	if t := p.next(); t.kind != kind {
+		return unexpected(t, what)
+	}
+	return nil
+}
+
+func unexpected(t token, what string) error {
+	if t.kind == tokEOF {
+		return fmt.Errorf("expected %s at end of policy", what)
+	}
+	return fmt.Errorf("expected %s at %d, got %q", what, t.pos, t.text)
+}
+
+// parse parses a policy into its syntax tree.
+func parse(s string) (*node, error) {
+	tokens, err := lex(s)
+	if nil != err {
+		return nil, err
+	}
+	p := parser{tokens: tokens}
+	n, err := p.expr()
+	if nil != err {
+		return nil, err
+	}
+	if t := p.next(); t.kind != tokEOF {
+		return nil, unexpected(t, "AND or OR")
+	}
+	return n, nil
+}
+
+func (p *parser) expr() (*node, error) {
+	return p.gate(or, tokOr, p.term)
+}
+
+func (p *parser) term() (*node, error) {
+	return p.gate(and, tokAnd, p.factor)
+}
+
+// gate parses operands separated by the operator tok into a gate of kind k,
+// or returns the operand alone if there is no operator.
+func (p *parser) gate(k kind, tok int, operand func() (*node, error)) (*node, error) {
+	n, err := operand()
+	if nil != err {
+		return nil, err
+	}
+	if p.peek().kind != tok {
+		return n, nil
+	}
+
+	g := &node{kind: k, children: []*node{n}}
+	for p.peek().kind == tok {
+		p.next()
+		if n, err = operand(); nil != err {
+			return nil, err
+		}
+		g.children = append(g.children, n)
+	}
+	if len(g.children) > maxChildren {
+		return nil, fmt.Errorf("gate cannot have more than %d operands", maxChildren)
+	}
+	return g, nil
+}
+
+func (p *parser) factor() (*node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokName:
+		n := &node{kind: leaf, name: t.text, index: p.leaves}
+		p.leaves++
+		return n, nil
+
+	case tokOpen:
+		n, err := p.expr()
+		if nil != err {
+			return nil, err
+		}
+		return n, p.expect(tokClose, "\")\"")
+
+	case tokOf:
+		if err := p.expect(tokOpen, "\"(\""); nil != err {
+			return nil, err
+		}
+		g := &node{kind: threshold, k: t.k}
+		for {
+			n, err := p.expr()
+			if nil != err {
+				return nil, err
+			}
+			g.children = append(g.children, n)
+			if p.peek().kind != tokComma {
+				break
+			}
+			p.next()
+		}
+		if err := p.expect(tokClose, "\",\" or \")\""); nil != err {
+			return nil, err
+		}
+		if g.k < 1 || g.k > len(g.children) {
+			return nil, fmt.Errorf("threshold at %d must be between 1 and the number of operands", t.pos)
+		}
+		if len(g.children) > maxChildren {
+			return nil, fmt.Errorf("gate cannot have more than %d operands", maxChildren)
+		}
+		return g, nil
+	}
+
+	return nil, unexpected(t, "participant, \"(\" or threshold")
+}
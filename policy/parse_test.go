@@ -0,0 +1,82 @@
+package policy
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	for in, exp := range map[string]string{
+		"alice":                    "alice",
+		"alice AND bob":            "alice AND bob",
+		"alice and bob or carol":   "alice AND bob OR carol",
+		"alice AND (bob OR carol)": "alice AND (bob OR carol)",
+		"(alice AND bob) OR 2of(carol, dave, erin)":  "alice AND bob OR 2of(carol, dave, erin)",
+		"2 OF (a, b OR c,d)":                         "2of(a, b OR c, d)",
+		"((alice))":                                  "alice",
+		"(a AND b) AND c":                            "(a AND b) AND c",
+		"a OR (b OR c)":                              "a OR (b OR c)",
+		"1of(ops@example.com, j.doe, x_y-z)":         "1of(ops@example.com, j.doe, x_y-z)",
+		"  alice\tAND\nbob  ":                        "alice AND bob",
+		"2of(alice AND bob, carol, 1of(dave, erin))": "2of(alice AND bob, carol, 1of(dave, erin))",
+		"2of(alice AND bob, carol) OR alice":         "2of(alice AND bob, carol) OR alice",
+		"2of(alice AND (alice OR bob), carol)":       "2of(alice AND (alice OR bob), carol)",
+		"2of(alice, bob OR alice, carol)":            "2of(alice, bob OR alice, carol)",
+		"1of(alice, 2of(bob, alice))":                "1of(alice, 2of(bob, alice))",
+	} {
+		p, err := Parse(in)
+		if err != nil {
+			t.Errorf("%q: err: %v", in, err)
+			continue
+		}
+		if out := p.String(); out != exp {
+			t.Errorf("%q: bad: %q", in, out)
+		}
+
+		// The canonical form parses to the same policy.
+		p2, err := Parse(p.String())
+		if err != nil || p2.String() != p.String() {
+			t.Errorf("%q: bad round trip: %v %v", in, p2, err)
+		}
+	}
+}
+
+func TestParse_invalid(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"alice AND",
+		"AND alice",
+		"alice bob",
+		"(alice",
+		"alice)",
+		"2(alice, bob)",
+		"2ofx(alice, bob)",
+		"2of alice, bob",
+		"3of(alice, bob)",
+		"0of(alice, bob)",
+		"2of(alice, bob",
+		"2of()",
+		"alice & bob",
+		"99999999999999999999of(a)",
+	} {
+		if _, err := Parse(in); err == nil {
+			t.Errorf("%q: expect error", in)
+		}
+	}
+}
+
+func TestPolicy_Participants(t *testing.T) {
+	p, err := Parse("(erin AND bob) OR 2of(carol, bob, alice)")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out := p.Participants()
+	exp := []string{"alice", "bob", "carol", "erin"}
+	if len(out) != len(exp) {
+		t.Fatalf("bad: %v", out)
+	}
+	for i := range exp {
+		if out[i] != exp[i] {
+			t.Fatalf("bad: %v", out)
+		}
+	}
+}
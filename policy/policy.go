@@ -0,0 +1,446 @@
+// Package policy splits secrets according to monotone boolean formulas over
+// named participants, such as
+//
+//	(alice AND bob) OR 2of(carol, dave, erin)
+//
+// AND and OR combine participants or nested formulas, binding in this order,
+// and kof(…) requires k of the comma separated formulas. The keywords are case
+// insensitive and parentheses group formulas. Participant names start with a
+// letter or underscore, followed by letters, digits or any of "_-.@".
+//
+// The secret is shared along the formula from the top: an AND gate splits its
+// value into random parts XORing to it, an OR gate hands its value to every
+// operand and a kof gate splits its value with shamir.Split using threshold k.
+// Each participant receives the values of all places it appears at in the
+// formula.
+package policy
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/corvus-ch/shamir"
+)
+
+var (
+	// ErrUnsatisfied is returned by Combine if the shares do not satisfy the
+	// policy.
+	ErrUnsatisfied = errors.New("shares do not satisfy the policy")
+
+	// ErrSetMismatch is returned by Combine if the shares were not created
+	// by the same split operation.
+	ErrSetMismatch = errors.New("shares do not belong to the same split")
+)
+
+// Option configures the optional behaviour of Split.
+type Option func(*config)
+
+type config struct {
+	rand io.Reader
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{rand: rand.Reader}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithRand sets the source of randomness. It defaults to crypto/rand.Reader.
+func WithRand(r io.Reader) Option {
+	return func(c *config) {
+		c.rand = r
+	}
+}
+
+// Policy is a parsed policy.
+type Policy struct {
+	root *node
+}
+
+// Parse parses a policy.
+func Parse(s string) (*Policy, error) {
+	root, err := parse(s)
+	if nil != err {
+		return nil, err
+	}
+	return &Policy{root: root}, nil
+}
+
+// String returns the policy in its canonical form.
+func (p *Policy) String() string {
+	return p.root.String()
+}
+
+// Participants returns the names of the participants in alphabetical order.
+func (p *Policy) Participants() []string {
+	seen := map[string]bool{}
+	var out []string
+	walk(p.root, func(n *node) {
+		if !seen[n.name] {
+			seen[n.name] = true
+			out = append(out, n.name)
+		}
+	})
+	sort.Strings(out)
+	return out
+}
+
+// walk calls fn for every leaf below n in the order of the policy.
+func walk(n *node, fn func(n *node)) {
+	if n.kind == leaf {
+		fn(n)
+		return
+	}
+	for _, c := range n.children {
+		walk(c, fn)
+	}
+}
+
+// Share holds the values a participant receives for a policy.
+type Share struct {
+	Participant string
+	// Policy is the canonical form of the policy the secret was split
+	// with.
+	Policy string
+	// SetID identifies the split operation.
+	SetID shamir.SetID
+	// Parts holds the values of each place the participant appears at in
+	// the policy.
+	Parts []Part
+}
+
+// Part is the value of a leaf of the policy.
+type Part struct {
+	// Leaf is the index of the leaf in the order of the policy, starting at
+	// zero.
+	Leaf  int
+	Value []byte
+}
+
+// Split splits the secret according to the policy and returns the share of
+// each participant.
+func Split(secret []byte, p *Policy, opts ...Option) (map[string]*Share, error) {
+	cfg := newConfig(opts)
+
+	var id shamir.SetID
+	if _, err := io.ReadFull(cfg.rand, id[:]); nil != err {
+		return nil, fmt.Errorf("failed to generate set id: %v", err)
+	}
+
+	policy := p.String()
+	out := map[string]*Share{}
+	err := share(cfg, p.root, secret, func(n *node, value []byte) {
+		s, ok := out[n.name]
+		if !ok {
+			s = &Share{Participant: n.name, Policy: policy, SetID: id}
+			out[n.name] = s
+		}
+		s.Parts = append(s.Parts, Part{Leaf: n.index, Value: value})
+	})
+	if nil != err {
+		return nil, err
+	}
+	return out, nil
+}
+
+// share shares value among the children of n and hands the values of the
+// leaves to fn.
+func share(cfg *config, n *node, value []byte, fn func(n *node, value []byte)) error {
+	switch n.kind {
+	case leaf:
+		fn(n, append([]byte(nil), value...))
+
+	case or:
+		for _, c := range n.children {
+			if err := share(cfg, c, value, fn); nil != err {
+				return err
+			}
+		}
+
+	case and:
+		last := append([]byte(nil), value...)
+		defer zero(last)
+		for _, c := range n.children[:len(n.children)-1] {
+			r := make([]byte, len(value))
+			if _, err := io.ReadFull(cfg.rand, r); nil != err {
+				return fmt.Errorf("failed to generate part: %v", err)
+			}
+			for i := range last {
+				last[i] ^= r[i]
+			}
+			err := share(cfg, c, r, fn)
+			zero(r)
+			if nil != err {
+				return err
+			}
+		}
+		return share(cfg, n.children[len(n.children)-1], last, fn)
+
+	case threshold:
+		if n.k == 1 {
+			return share(cfg, &node{kind: or, children: n.children}, value, fn)
+		}
+		parts, err := shamir.Split(value, len(n.children), n.k, shamir.WithRand(cfg.rand), shamir.WithCoordinates(coordinates(len(n.children))...))
+		if nil != err {
+			return err
+		}
+		for i, c := range n.children {
+			err := share(cfg, c, parts[byte(i+1)], fn)
+			zero(parts[byte(i+1)])
+			if nil != err {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// coordinates returns the x coordinates of the operands of a threshold gate.
+func coordinates(n int) []byte {
+	xs := make([]byte, n)
+	for i := range xs {
+		xs[i] = byte(i + 1)
+	}
+	return xs
+}
+
+// Combine reconstructs the secret from the shares of some participants. It
+// returns the clause of the policy satisfied by the participants, leaving out
+// any of them not required. If several clauses are satisfied, the first one
+// in the order of the policy is used.
+//
+// A share given more than once is used once. Parts of a leaf not belonging
+// to the participant of the share, or parts of the same leaf with different
+// values, are refused.
+func Combine(shares ...*Share) ([]byte, string, error) {
+	if len(shares) == 0 {
+		return nil, "", ErrUnsatisfied
+	}
+
+	p, err := Parse(shares[0].Policy)
+	if nil != err {
+		return nil, "", err
+	}
+
+	names := map[int]string{}
+	walk(p.root, func(n *node) {
+		names[n.index] = n.name
+	})
+
+	values := map[int][]byte{}
+	for _, s := range shares {
+		if s.Policy != shares[0].Policy || s.SetID != shares[0].SetID {
+			return nil, "", ErrSetMismatch
+		}
+		for _, part := range s.Parts {
+			if names[part.Leaf] != s.Participant {
+				return nil, "", fmt.Errorf("leaf %d does not belong to participant %s", part.Leaf, s.Participant)
+			}
+			if v, ok := values[part.Leaf]; ok {
+				if !bytes.Equal(v, part.Value) {
+					return nil, "", fmt.Errorf("conflicting parts for leaf %d", part.Leaf)
+				}
+				continue
+			}
+			values[part.Leaf] = part.Value
+		}
+	}
+
+	value, clause, err := combine(p.root, values)
+	if nil != err {
+		return nil, "", err
+	}
+	if nil == clause {
+		return nil, "", ErrUnsatisfied
+	}
+	return append([]byte(nil), value...), clause.String(), nil
+}
+
+// combine reconstructs the value of n from the values of the leaves. It
+// returns the satisfied clause below n, or nil if n is not satisfied.
+func combine(n *node, values map[int][]byte) ([]byte, *node, error) {
+	switch n.kind {
+	case leaf:
+		if v, ok := values[n.index]; ok {
+			return v, n, nil
+		}
+		return nil, nil, nil
+
+	case or:
+		for _, c := range n.children {
+			v, clause, err := combine(c, values)
+			if nil != err || nil != clause {
+				return v, clause, err
+			}
+		}
+		return nil, nil, nil
+
+	case and:
+		var out []byte
+		clause := &node{kind: and}
+		for _, c := range n.children {
+			v, cc, err := combine(c, values)
+			if nil != err || nil == cc {
+				return nil, nil, err
+			}
+			if nil == out {
+				out = make([]byte, len(v))
+			}
+			if len(v) != len(out) {
+				return nil, nil, fmt.Errorf("all parts must be the same length")
+			}
+			for i := range out {
+				out[i] ^= v[i]
+			}
+			clause.children = append(clause.children, cc)
+		}
+		return out, clause, nil
+
+	case threshold:
+		// Split hands out the value of a 1of gate like an OR gate.
+		if n.k == 1 {
+			return combine(&node{kind: or, children: n.children}, values)
+		}
+		parts := make(map[byte][]byte, n.k)
+		clause := &node{kind: threshold, k: n.k}
+		for i, c := range n.children {
+			v, cc, err := combine(c, values)
+			if nil != err {
+				return nil, nil, err
+			}
+			if nil == cc {
+				continue
+			}
+			parts[byte(i+1)] = v
+			clause.children = append(clause.children, cc)
+			if len(parts) == n.k {
+				break
+			}
+		}
+		if len(parts) < n.k {
+			return nil, nil, nil
+		}
+		out, err := shamir.Combine(parts)
+		if nil != err {
+			return nil, nil, err
+		}
+		return out, clause, nil
+	}
+	return nil, nil, fmt.Errorf("invalid policy")
+}
+
+const (
+	shareMagic   = "GFSP"
+	shareVersion = 1
+)
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+//
+// The binary encoding of a share consists of the magic "GFSP", the format
+// version 1, the set identifier, the participant and the policy, each
+// prefixed by its length as 2 byte big endian number, and the number of parts
+// as single byte followed by each part as 2 byte big endian leaf index and the
+// value prefixed by its length as 4 byte big endian number.
+func (s *Share) MarshalBinary() ([]byte, error) {
+	if len(s.Participant) > 0xffff || len(s.Policy) > 0xffff {
+		return nil, fmt.Errorf("participant or policy too long")
+	}
+	if len(s.Parts) > 255 {
+		return nil, fmt.Errorf("share cannot have more than 255 parts")
+	}
+
+	out := append([]byte(shareMagic), shareVersion)
+	out = append(out, s.SetID[:]...)
+	out = appendString(out, s.Participant)
+	out = appendString(out, s.Policy)
+	out = append(out, byte(len(s.Parts)))
+	for _, p := range s.Parts {
+		if p.Leaf < 0 || p.Leaf > 0xffff {
+			return nil, fmt.Errorf("invalid leaf index %d", p.Leaf)
+		}
+		var b [6]byte
+		binary.BigEndian.PutUint16(b[:2], uint16(p.Leaf))
+		binary.BigEndian.PutUint32(b[2:], uint32(len(p.Value)))
+		out = append(append(out, b[:]...), p.Value...)
+	}
+	return out, nil
+}
+
+func appendString(b []byte, s string) []byte {
+	var n [2]byte
+	binary.BigEndian.PutUint16(n[:], uint16(len(s)))
+	return append(append(b, n[:]...), s...)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (s *Share) UnmarshalBinary(data []byte) error {
+	r := reader{data: data}
+	if string(r.read(4)) != shareMagic {
+		return fmt.Errorf("not a policy share")
+	}
+	if v := r.read(1); nil != v && v[0] != shareVersion {
+		return fmt.Errorf("unsupported share version %d", v[0])
+	}
+	var id shamir.SetID
+	copy(id[:], r.read(len(id)))
+	participant := string(r.read(int(r.uint(2))))
+	policy := string(r.read(int(r.uint(2))))
+	parts := make([]Part, r.uint(1))
+	for i := range parts {
+		parts[i].Leaf = int(r.uint(2))
+		parts[i].Value = append([]byte(nil), r.read(int(r.uint(4)))...)
+	}
+	if r.short {
+		return fmt.Errorf("share is too short")
+	}
+	if len(r.data) != 0 {
+		return fmt.Errorf("trailing data after share")
+	}
+	if _, err := Parse(policy); nil != err {
+		return fmt.Errorf("invalid policy: %v", err)
+	}
+
+	s.Participant = participant
+	s.Policy = policy
+	s.SetID = id
+	s.Parts = parts
+	return nil
+}
+
+// reader consumes data, remembering if it ran short.
+type reader struct {
+	data  []byte
+	short bool
+}
+
+func (r *reader) read(n int) []byte {
+	if r.short || n > len(r.data) {
+		r.short = true
+		return nil
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *reader) uint(n int) uint64 {
+	var v uint64
+	for _, b := range r.read(n) {
+		v = v<<8 | uint64(b)
+	}
+	return v
+}
+
+// zero overwrites b with zeros.
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,172 @@
+package policy
+
+import (
+	"bytes"
+	"testing"
+)
+
+func testSplit(t *testing.T, policy string, secret []byte) map[string]*Share {
+	t.Helper()
+	p, err := Parse(policy)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	shares, err := Split(secret, p)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return shares
+}
+
+func TestCombine(t *testing.T) {
+	secret := []byte("test")
+	shares := testSplit(t, "(alice AND bob) OR 2of(carol, dave, erin)", secret)
+	if len(shares) != 5 {
+		t.Fatalf("bad: %v", shares)
+	}
+
+	for _, tc := range []struct {
+		names  []string
+		clause string
+	}{
+		{[]string{"alice", "bob"}, "alice AND bob"},
+		{[]string{"bob", "alice", "dave"}, "alice AND bob"},
+		{[]string{"carol", "erin"}, "2of(carol, erin)"},
+		{[]string{"erin", "dave", "carol"}, "2of(carol, dave)"},
+		{[]string{"alice", "bob", "carol", "dave", "erin"}, "alice AND bob"},
+		{[]string{"alice", "erin", "dave"}, "2of(dave, erin)"},
+	} {
+		var in []*Share
+		for _, n := range tc.names {
+			in = append(in, shares[n])
+		}
+		out, clause, err := Combine(in...)
+		if err != nil {
+			t.Fatalf("%v: err: %v", tc.names, err)
+		}
+		if !bytes.Equal(out, secret) || clause != tc.clause {
+			t.Fatalf("%v: bad: %q %q", tc.names, out, clause)
+		}
+	}
+
+	for _, names := range [][]string{
+		{"alice"},
+		{"alice", "carol"},
+		{"bob", "erin"},
+		{"dave"},
+	} {
+		var in []*Share
+		for _, n := range names {
+			in = append(in, shares[n])
+		}
+		if _, _, err := Combine(in...); err != ErrUnsatisfied {
+			t.Fatalf("%v: bad: %v", names, err)
+		}
+	}
+	if _, _, err := Combine(); err != ErrUnsatisfied {
+		t.Fatalf("bad: %v", err)
+	}
+}
+
+func TestCombine_nested(t *testing.T) {
+	secret := []byte("a longer secret of some bytes")
+	shares := testSplit(t, "3of(alice AND bob, alice OR carol, 1of(dave, erin), frank, (gina AND hank) AND ivan)", secret)
+	if len(shares["alice"].Parts) != 2 {
+		t.Fatalf("bad: %v", shares["alice"])
+	}
+
+	for _, tc := range []struct {
+		names  []string
+		clause string
+	}{
+		{[]string{"alice", "bob", "erin"}, "3of(alice AND bob, alice, erin)"},
+		{[]string{"carol", "frank", "dave"}, "3of(carol, dave, frank)"},
+		{[]string{"gina", "hank", "ivan", "frank", "alice"}, "3of(alice, frank, (gina AND hank) AND ivan)"},
+	} {
+		var in []*Share
+		for _, n := range tc.names {
+			in = append(in, shares[n])
+		}
+		out, clause, err := Combine(in...)
+		if err != nil {
+			t.Fatalf("%v: err: %v", tc.names, err)
+		}
+		if !bytes.Equal(out, secret) || clause != tc.clause {
+			t.Fatalf("%v: bad: %q %q", tc.names, out, clause)
+		}
+	}
+
+	if _, _, err := Combine(shares["alice"], shares["gina"], shares["ivan"], shares["dave"]); err != ErrUnsatisfied {
+		t.Fatalf("bad: %v", err)
+	}
+}
+
+func TestCombine_foreignLeaf(t *testing.T) {
+	shares := testSplit(t, "alice AND bob OR carol", []byte("test"))
+
+	// Bob claims the part of carol's leaf with the value of his own.
+	forged := *shares["bob"]
+	forged.Parts = []Part{{Leaf: shares["carol"].Parts[0].Leaf, Value: shares["bob"].Parts[0].Value}}
+	if _, _, err := Combine(&forged); err == nil {
+		t.Fatalf("expect error")
+	}
+
+	// Repeating a share is harmless, changing its value is not.
+	out, _, err := Combine(shares["alice"], shares["alice"], shares["bob"])
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(out, []byte("test")) {
+		t.Fatalf("bad: %q", out)
+	}
+	changed := *shares["alice"]
+	changed.Parts = []Part{{Leaf: changed.Parts[0].Leaf, Value: []byte("tess")}}
+	if _, _, err := Combine(shares["alice"], &changed, shares["bob"]); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestCombine_setMismatch(t *testing.T) {
+	policy := "alice AND bob"
+	a := testSplit(t, policy, []byte("test"))
+	b := testSplit(t, policy, []byte("test"))
+
+	if _, _, err := Combine(a["alice"], b["bob"]); err != ErrSetMismatch {
+		t.Fatalf("bad: %v", err)
+	}
+}
+
+func TestShare_MarshalBinary(t *testing.T) {
+	secret := []byte("test")
+	shares := testSplit(t, "alice AND 2of(bob, alice, carol)", secret)
+
+	decoded := map[string]*Share{}
+	for name, s := range shares {
+		data, err := s.MarshalBinary()
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		var out Share
+		if err := out.UnmarshalBinary(data); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if out.Participant != name || out.Policy != s.Policy || out.SetID != s.SetID || len(out.Parts) != len(s.Parts) {
+			t.Fatalf("bad: %v", out)
+		}
+		decoded[name] = &out
+
+		for _, in := range [][]byte{data[:len(data)-1], append(data[:len(data):len(data)], 0), []byte("GFSS"), nil} {
+			if err := out.UnmarshalBinary(in); err == nil {
+				t.Errorf("%q: expect error", in)
+			}
+		}
+	}
+
+	out, clause, err := Combine(decoded["alice"], decoded["carol"])
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(out, secret) || clause != "alice AND 2of(alice, carol)" {
+		t.Fatalf("bad: %q %q", out, clause)
+	}
+}
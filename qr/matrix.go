@@ -0,0 +1,313 @@
+package qr
+
+// matrix holds the modules of a symbol under construction together with the
+// modules reserved for function patterns.
+type matrix struct {
+	size     int
+	modules  []bool
+	function []bool
+}
+
+func (m *matrix) set(x, y int, dark bool) {
+	m.modules[y*m.size+x] = dark
+	m.function[y*m.size+x] = true
+}
+
+// newCode lays out the codewords in a symbol of the given version. A negative
+// mask selects the mask with the lowest penalty.
+func newCode(version int, level Level, codewords []byte, mask int) *Code {
+	size := 4*version + 17
+	m := &matrix{
+		size:     size,
+		modules:  make([]bool, size*size),
+		function: make([]bool, size*size),
+	}
+	m.drawFunctionPatterns(version)
+	m.drawCodewords(codewords)
+
+	if mask < 0 {
+		best := -1
+		for i := 0; i < 8; i++ {
+			m.applyMask(i)
+			m.drawFormatBits(level, i)
+			if p := m.penalty(); mask < 0 || p < best {
+				mask, best = i, p
+			}
+			m.applyMask(i)
+		}
+	}
+	m.applyMask(mask)
+	m.drawFormatBits(level, mask)
+
+	return &Code{Size: size, Version: version, Level: level, Mask: mask, modules: m.modules}
+}
+
+func (m *matrix) drawFunctionPatterns(version int) {
+	for i := 0; i < m.size; i++ {
+		m.set(6, i, i%2 == 0)
+		m.set(i, 6, i%2 == 0)
+	}
+
+	m.drawFinder(3, 3)
+	m.drawFinder(m.size-4, 3)
+	m.drawFinder(3, m.size-4)
+
+	pos := alignmentPositions(version)
+	last := len(pos) - 1
+	for i, x := range pos {
+		for j, y := range pos {
+			// Skip the corners occupied by finder patterns.
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			m.drawAlignment(x, y)
+		}
+	}
+
+	// Reserve the format information, it is drawn once the mask is known.
+	m.drawFormatBits(L, 0)
+	m.drawVersion(version)
+}
+
+// drawFinder draws a finder pattern and its separator centred at x, y.
+func (m *matrix) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || yy < 0 || xx >= m.size || yy >= m.size {
+				continue
+			}
+			d := max(abs(dx), abs(dy))
+			m.set(xx, yy, d != 2 && d != 4)
+		}
+	}
+}
+
+// drawAlignment draws an alignment pattern centred at x, y.
+func (m *matrix) drawAlignment(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			m.set(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// alignmentPositions returns the row and column coordinates of the centres of
+// the alignment patterns in ascending order.
+func alignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	n := version/7 + 2
+	step := (version*8 + n*3 + 5) / (n*4 - 4) * 2
+	pos := make([]int, n)
+	pos[0] = 6
+	for i, p := n-1, 4*version+10; i > 0; i, p = i-1, p-step {
+		pos[i] = p
+	}
+	return pos
+}
+
+func (m *matrix) drawFormatBits(level Level, mask int) {
+	data := level.formatBits()<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return bits>>uint(i)&1 == 1 }
+
+	// Around the top left finder pattern.
+	for i := 0; i <= 5; i++ {
+		m.set(8, i, bit(i))
+	}
+	m.set(8, 7, bit(6))
+	m.set(8, 8, bit(7))
+	m.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		m.set(14-i, 8, bit(i))
+	}
+
+	// Split between the other two finder patterns.
+	for i := 0; i < 8; i++ {
+		m.set(m.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		m.set(8, m.size-15+i, bit(i))
+	}
+	m.set(8, m.size-8, true)
+}
+
+func (m *matrix) drawVersion(version int) {
+	if version < 7 {
+		return
+	}
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = rem<<1 ^ (rem>>11)*0x1f25
+	}
+	bits := version<<12 | rem
+	for i := 0; i < 18; i++ {
+		dark := bits>>uint(i)&1 == 1
+		a, b := m.size-11+i%3, i/3
+		m.set(a, b, dark)
+		m.set(b, a, dark)
+	}
+}
+
+// drawCodewords places the codewords in the two module wide columns zigzagging
+// from the bottom right corner, skipping the function patterns. Remainder
+// modules stay light.
+func (m *matrix) drawCodewords(codewords []byte) {
+	i := 0
+	for right := m.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < m.size; vert++ {
+			y := vert
+			if upward {
+				y = m.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if m.function[y*m.size+x] || i >= len(codewords)*8 {
+					continue
+				}
+				m.modules[y*m.size+x] = codewords[i>>3]>>uint(7-i&7)&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+// applyMask inverts the data modules selected by the mask. Applying the same
+// mask twice undoes it.
+func (m *matrix) applyMask(mask int) {
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !m.function[y*m.size+x] {
+				m.modules[y*m.size+x] = !m.modules[y*m.size+x]
+			}
+		}
+	}
+}
+
+// Penalty weights of the mask evaluation rules.
+const (
+	penaltyRun     = 3
+	penaltyBlock   = 3
+	penaltyFinder  = 40
+	penaltyBalance = 10
+)
+
+// penalty scores the symbol by the four mask evaluation rules, lower is
+// better.
+func (m *matrix) penalty() int {
+	p := 0
+	at := func(x, y int, transpose bool) bool {
+		if transpose {
+			x, y = y, x
+		}
+		return m.modules[y*m.size+x]
+	}
+
+	for _, transpose := range []bool{false, true} {
+		for y := 0; y < m.size; y++ {
+			// Runs of five or more modules of the same colour.
+			run := 1
+			for x := 1; x < m.size; x++ {
+				if at(x, y, transpose) == at(x-1, y, transpose) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					p += penaltyRun + run - 5
+				}
+				run = 1
+			}
+			if run >= 5 {
+				p += penaltyRun + run - 5
+			}
+
+			// Patterns resembling a finder pattern, 1:1:3:1:1 preceded or
+			// followed by four light modules.
+			for x := 0; x+11 <= m.size; x++ {
+				if matches(at, x, y, transpose, finderBefore) || matches(at, x, y, transpose, finderAfter) {
+					p += penaltyFinder
+				}
+			}
+		}
+	}
+
+	// Blocks of two by two modules of the same colour.
+	for y := 0; y+1 < m.size; y++ {
+		for x := 0; x+1 < m.size; x++ {
+			c := m.modules[y*m.size+x]
+			if c == m.modules[y*m.size+x+1] && c == m.modules[(y+1)*m.size+x] && c == m.modules[(y+1)*m.size+x+1] {
+				p += penaltyBlock
+			}
+		}
+	}
+
+	// Deviation of the proportion of dark modules from one half.
+	dark := 0
+	for _, d := range m.modules {
+		if d {
+			dark++
+		}
+	}
+	total := len(m.modules)
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	p += k * penaltyBalance
+
+	return p
+}
+
+var (
+	finderBefore = []bool{false, false, false, false, true, false, true, true, true, false, true}
+	finderAfter  = []bool{true, false, true, true, true, false, true, false, false, false, false}
+)
+
+func matches(at func(x, y int, transpose bool) bool, x, y int, transpose bool, pattern []bool) bool {
+	for i, dark := range pattern {
+		if at(x+i, y, transpose) != dark {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
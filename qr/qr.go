@@ -0,0 +1,362 @@
+// Package qr renders shares as QR codes for paper backups.
+//
+// The encoder is a pure Go implementation of ISO/IEC 18004 supporting the
+// byte and alphanumeric modes, all versions and error correction levels as
+// well as structured append, which spreads data too large for a single symbol
+// over up to 16 symbols. Symbols are rendered as PNG, SVG or text of Unicode
+// block characters for terminals.
+//
+// Shares are encoded in their bech32m text form in upper case, which uses the
+// compact alphanumeric mode and is understood by any scanner.
+package qr
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/corvus-ch/shamir"
+	"github.com/corvus-ch/shamir/bech32m"
+)
+
+// Level is the error correction level of a symbol.
+type Level int
+
+// The error correction levels, recovering about 7%, 15%, 25% and 30% of the
+// symbol respectively.
+const (
+	L Level = iota
+	M
+	Q
+	H
+)
+
+// formatBits returns the bits of the level used in the format information.
+func (l Level) formatBits() int {
+	return [4]int{1, 0, 3, 2}[l]
+}
+
+const (
+	minVersion = 1
+	maxVersion = 40
+	// maxSymbols is the largest number of symbols of a structured append
+	// sequence.
+	maxSymbols = 16
+)
+
+// mode is an encoding mode with its indicator and the sizes of the character
+// count by version range.
+type mode struct {
+	indicator int
+	countBits [3]int
+}
+
+var (
+	modeByte  = mode{0x4, [3]int{8, 16, 16}}
+	modeAlnum = mode{0x2, [3]int{9, 11, 13}}
+)
+
+// alnumChars are the characters of the alphanumeric mode in the order of
+// their values.
+const alnumChars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+func (m *mode) countBitsFor(version int) int {
+	switch {
+	case version <= 9:
+		return m.countBits[0]
+	case version <= 26:
+		return m.countBits[1]
+	default:
+		return m.countBits[2]
+	}
+}
+
+// modeFor returns the most compact mode able to encode data.
+func modeFor(data []byte) *mode {
+	for _, c := range data {
+		if strings.IndexByte(alnumChars, c) < 0 {
+			return &modeByte
+		}
+	}
+	return &modeAlnum
+}
+
+// Code is a QR symbol.
+type Code struct {
+	// Size is the width and height of the symbol in modules, excluding the
+	// quiet zone.
+	Size    int
+	Version int
+	Level   Level
+	Mask    int
+
+	modules []bool
+}
+
+// Black reports whether the module at column x and row y is dark. Modules
+// outside the symbol are light.
+func (c *Code) Black(x, y int) bool {
+	return x >= 0 && y >= 0 && x < c.Size && y < c.Size && c.modules[y*c.Size+x]
+}
+
+// Encode encodes data into a single symbol of the smallest version able to
+// hold it at the given level.
+func Encode(data []byte, level Level) (*Code, error) {
+	return encode(data, level, nil, maxVersion)
+}
+
+// EncodeStructured encodes data into as few symbols of at most the given
+// version as possible, using structured append if more than one symbol is
+// required. Scanners supporting structured append concatenate the data of
+// the symbols.
+func EncodeStructured(data []byte, level Level, maxVer int) ([]*Code, error) {
+	if level < L || level > H {
+		return nil, fmt.Errorf("invalid error correction level")
+	}
+	if maxVer < minVersion || maxVer > maxVersion {
+		return nil, fmt.Errorf("version must be between %d and %d", minVersion, maxVersion)
+	}
+
+	if c, err := encode(data, level, nil, maxVer); nil == err {
+		return []*Code{c}, nil
+	}
+
+	c := capacity(modeFor(data), level, maxVer, true)
+	if c < 1 || len(data) > maxSymbols*c {
+		return nil, fmt.Errorf("data too long for %d symbols of version %d", maxSymbols, maxVer)
+	}
+	n := (len(data) + c - 1) / c
+
+	var parity byte
+	for _, b := range data {
+		parity ^= b
+	}
+
+	// Spread the data evenly, so all symbols are of about the same size.
+	codes := make([]*Code, n)
+	for i := range codes {
+		chunk := data[i*len(data)/n : (i+1)*len(data)/n]
+		sa := &structuredAppend{index: i, total: n, parity: parity}
+		code, err := encode(chunk, level, sa, maxVer)
+		if nil != err {
+			return nil, err
+		}
+		codes[i] = code
+	}
+	return codes, nil
+}
+
+// EncodeShare encodes the share in its bech32m text form using the prefix
+// bech32m.DefaultHRP into symbols of at most the given version.
+func EncodeShare(s *shamir.Share, level Level, maxVer int) ([]*Code, error) {
+	str, err := bech32m.Encode(bech32m.DefaultHRP, s)
+	if nil != err {
+		return nil, err
+	}
+	return EncodeStructured([]byte(strings.ToUpper(str)), level, maxVer)
+}
+
+// structuredAppend holds the header of a symbol of a structured append
+// sequence.
+type structuredAppend struct {
+	index, total int
+	parity       byte
+}
+
+const structuredAppendBits = 4 + 4 + 4 + 8
+
+// capacity returns the number of characters a symbol of the version and
+// level holds in the given mode.
+func capacity(m *mode, level Level, version int, sa bool) int {
+	bits := 8*numDataCodewords(version, level) - 4 - m.countBitsFor(version)
+	if sa {
+		bits -= structuredAppendBits
+	}
+	n := 0
+	if m == &modeByte {
+		n = bits / 8
+	} else {
+		n = bits / 11 * 2
+		if bits%11 >= 6 {
+			n++
+		}
+	}
+	if max := 1<<m.countBitsFor(version) - 1; n > max {
+		n = max
+	}
+	return n
+}
+
+func encode(data []byte, level Level, sa *structuredAppend, maxVer int) (*Code, error) {
+	version, codewords, err := dataCodewords(data, level, sa, maxVer)
+	if nil != err {
+		return nil, err
+	}
+	codewords = addErrorCorrection(codewords, version, level)
+	return newCode(version, level, codewords, -1), nil
+}
+
+// dataCodewords selects the smallest version able to hold data and returns
+// it together with the data codewords of the symbol.
+func dataCodewords(data []byte, level Level, sa *structuredAppend, maxVer int) (int, []byte, error) {
+	if level < L || level > H {
+		return 0, nil, fmt.Errorf("invalid error correction level")
+	}
+
+	m := modeFor(data)
+	version := minVersion
+	for ; version <= maxVer; version++ {
+		if len(data) <= capacity(m, level, version, nil != sa) {
+			break
+		}
+	}
+	if version > maxVer {
+		return 0, nil, fmt.Errorf("data too long for a symbol of version %d", maxVer)
+	}
+
+	var bb bitBuffer
+	if nil != sa {
+		bb.append(0x3, 4)
+		bb.append(sa.index, 4)
+		bb.append(sa.total-1, 4)
+		bb.append(int(sa.parity), 8)
+	}
+	bb.append(m.indicator, 4)
+	bb.append(len(data), m.countBitsFor(version))
+	if m == &modeByte {
+		for _, b := range data {
+			bb.append(int(b), 8)
+		}
+	} else {
+		for i := 0; i+1 < len(data); i += 2 {
+			bb.append(45*strings.IndexByte(alnumChars, data[i])+strings.IndexByte(alnumChars, data[i+1]), 11)
+		}
+		if len(data)%2 == 1 {
+			bb.append(strings.IndexByte(alnumChars, data[len(data)-1]), 6)
+		}
+	}
+
+	// Add the terminator, pad to a byte boundary and fill up with the
+	// alternating pad bytes.
+	total := 8 * numDataCodewords(version, level)
+	terminator := total - len(bb)
+	if terminator > 4 {
+		terminator = 4
+	}
+	bb.append(0, terminator)
+	bb.append(0, (8-len(bb)%8)%8)
+	for pad := 0xec; len(bb) < total; pad ^= 0xec ^ 0x11 {
+		bb.append(pad, 8)
+	}
+
+	return version, bb.bytes(), nil
+}
+
+type bitBuffer []bool
+
+func (bb *bitBuffer) append(v, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*bb = append(*bb, v>>uint(i)&1 == 1)
+	}
+}
+
+func (bb bitBuffer) bytes() []byte {
+	out := make([]byte, len(bb)/8)
+	for i, b := range bb {
+		if b {
+			out[i/8] |= 0x80 >> uint(i%8)
+		}
+	}
+	return out
+}
+
+// numRawDataModules returns the number of modules available for data and
+// error correction codewords, including remainder bits.
+func numRawDataModules(version int) int {
+	n := (16*version+128)*version + 64
+	if version >= 2 {
+		numAlign := version/7 + 2
+		n -= (25*numAlign-10)*numAlign - 55
+		if version >= 7 {
+			n -= 36
+		}
+	}
+	return n
+}
+
+func numDataCodewords(version int, level Level) int {
+	return numRawDataModules(version)/8 - eccCodewordsPerBlock[level][version]*numErrorCorrectionBlocks[level][version]
+}
+
+// addErrorCorrection splits the data into blocks, appends the Reed-Solomon
+// error correction codewords to each block and interleaves the blocks.
+func addErrorCorrection(data []byte, version int, level Level) []byte {
+	numBlocks := numErrorCorrectionBlocks[level][version]
+	eccLen := eccCodewordsPerBlock[level][version]
+	raw := numRawDataModules(version) / 8
+	numShort := numBlocks - raw%numBlocks
+	shortLen := raw / numBlocks
+
+	divisor := rsDivisor(eccLen)
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := range blocks {
+		n := shortLen - eccLen
+		if i >= numShort {
+			n++
+		}
+		block := append([]byte(nil), data[k:k+n]...)
+		k += n
+		blocks[i] = append(block, rsRemainder(block, divisor)...)
+	}
+
+	// Interleave the data codewords, short blocks lack the last one, followed
+	// by the error correction codewords.
+	out := make([]byte, 0, raw)
+	for i := 0; i <= shortLen-eccLen; i++ {
+		for _, block := range blocks {
+			if i < len(block)-eccLen {
+				out = append(out, block[i])
+			}
+		}
+	}
+	for i := 0; i < eccLen; i++ {
+		for _, block := range blocks {
+			out = append(out, block[len(block)-eccLen+i])
+		}
+	}
+	return out
+}
+
+// rsDivisor returns the generator polynomial of the given degree, without its
+// leading coefficient, highest degree first.
+func rsDivisor(degree int) []byte {
+	f := shamir.DefaultField
+	out := make([]byte, degree)
+	out[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range out {
+			out[j] = f.Mul(out[j], root)
+			if j+1 < len(out) {
+				out[j] ^= out[j+1]
+			}
+		}
+		root = f.Mul(root, 2)
+	}
+	return out
+}
+
+// rsRemainder returns the error correction codewords of data.
+func rsRemainder(data, divisor []byte) []byte {
+	f := shamir.DefaultField
+	out := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ out[0]
+		copy(out, out[1:])
+		out[len(out)-1] = 0
+		for i, c := range divisor {
+			out[i] ^= f.Mul(c, factor)
+		}
+	}
+	return out
+}
@@ -0,0 +1,407 @@
+package qr
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/corvus-ch/shamir"
+	"github.com/corvus-ch/shamir/bech32m"
+)
+
+func TestDataCodewords(t *testing.T) {
+	// Worked example of version 1-M from the QR code tutorial at thonky.com.
+	version, data, err := dataCodewords([]byte("HELLO WORLD"), M, nil, maxVersion)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	exp := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	if version != 1 || !bytes.Equal(data, exp) {
+		t.Fatalf("bad: %d %v", version, data)
+	}
+
+	out := addErrorCorrection(data, version, M)
+	if ecc := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}; !bytes.Equal(out[len(data):], ecc) {
+		t.Fatalf("bad: %v", out[len(data):])
+	}
+}
+
+func TestAlignmentPositions(t *testing.T) {
+	for v, exp := range map[int][]int{
+		1:  nil,
+		2:  {6, 18},
+		7:  {6, 22, 38},
+		32: {6, 34, 60, 86, 112, 138},
+		40: {6, 30, 58, 86, 114, 142, 170},
+	} {
+		if out := alignmentPositions(v); !equalInts(out, exp) {
+			t.Errorf("%d: bad: %v", v, out)
+		}
+	}
+}
+
+func TestNumRawDataModules(t *testing.T) {
+	for v, exp := range map[int]int{1: 208, 2: 359, 7: 1568, 40: 29648} {
+		if out := numRawDataModules(v); out != exp {
+			t.Errorf("%d: bad: %d", v, out)
+		}
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	// Capacities as listed in ISO/IEC 18004.
+	for _, tc := range []struct {
+		mode    *mode
+		level   Level
+		version int
+		exp     int
+	}{
+		{&modeByte, L, 1, 17},
+		{&modeByte, H, 1, 7},
+		{&modeAlnum, M, 1, 20},
+		{&modeByte, L, 40, 2953},
+		{&modeAlnum, L, 40, 4296},
+		{&modeByte, H, 40, 1273},
+	} {
+		if out := capacity(tc.mode, tc.level, tc.version, false); out != tc.exp {
+			t.Errorf("%d-%d: bad: %d", tc.version, tc.level, out)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	for _, data := range []string{"", "HELLO WORLD", "hello, world", strings.Repeat("\x00\xff", 500)} {
+		for level := L; level <= H; level++ {
+			c, err := Encode([]byte(data), level)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			out, sa := scan(t, c)
+			if sa != nil || out != data {
+				t.Fatalf("bad: %q %v", out, sa)
+			}
+		}
+	}
+}
+
+func TestEncode_invalid(t *testing.T) {
+	if _, err := Encode(make([]byte, 2954), L); err == nil {
+		t.Fatal("expect error")
+	}
+	if _, err := Encode(nil, H+1); err == nil {
+		t.Fatal("expect error")
+	}
+}
+
+func TestEncodeStructured(t *testing.T) {
+	data := strings.Repeat("SHAMIR SECRET SHARING ", 20)
+	codes, err := EncodeStructured([]byte(data), M, 5)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(codes) < 2 {
+		t.Fatalf("bad: %d", len(codes))
+	}
+
+	var parity byte
+	for _, c := range []byte(data) {
+		parity ^= c
+	}
+	var out string
+	for i, c := range codes {
+		if c.Version > 5 {
+			t.Fatalf("bad: %d", c.Version)
+		}
+		chunk, sa := scan(t, c)
+		if sa == nil || sa.index != i || sa.total != len(codes) || sa.parity != parity {
+			t.Fatalf("bad: %v", sa)
+		}
+		out += chunk
+	}
+	if out != data {
+		t.Fatalf("bad: %q", out)
+	}
+}
+
+func TestEncodeStructured_single(t *testing.T) {
+	codes, err := EncodeStructured([]byte("HELLO WORLD"), M, 1)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out, sa := scan(t, codes[0]); len(codes) != 1 || sa != nil || out != "HELLO WORLD" {
+		t.Fatalf("bad: %d %q %v", len(codes), out, sa)
+	}
+}
+
+func TestEncodeStructured_invalid(t *testing.T) {
+	for _, v := range []int{0, 41} {
+		if _, err := EncodeStructured(nil, L, v); err == nil {
+			t.Errorf("%d: expect error", v)
+		}
+	}
+	if _, err := EncodeStructured(make([]byte, 16*17), L, 1); err == nil {
+		t.Fatal("expect error")
+	}
+}
+
+func TestEncodeShare(t *testing.T) {
+	for _, n := range []int{10, 1000} {
+		shares, err := shamir.SplitShares(bytes.Repeat([]byte("secret"), n), 3, 2)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		s := shares[0]
+
+		codes, err := EncodeShare(s, Q, 40)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		var str string
+		for _, c := range codes {
+			chunk, _ := scan(t, c)
+			str += chunk
+		}
+		if str != strings.ToUpper(str) {
+			t.Fatalf("bad: %s", str)
+		}
+
+		_, out, err := bech32m.Decode(str)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if out.X != s.X || !bytes.Equal(out.Value, s.Value) {
+			t.Fatalf("bad: %v", out)
+		}
+	}
+}
+
+// The data of the golden symbols in testdata, see testdata/generate.go.
+const (
+	goldenByte       = "https://github.com/corvus-ch/shamir/blob/master/README.md#shamirs-secret-sharing"
+	goldenStructured = "Split a secret into parts, a threshold of which recover the secret."
+)
+
+func TestEncode_golden(t *testing.T) {
+	for level := L; level <= H; level++ {
+		c, err := Encode([]byte(goldenByte), level)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		name := "byte-" + "LMQH"[level:level+1] + ".txt"
+		if out, exp := render(c.Size, c.modules), golden(t, name); out != exp {
+			t.Fatalf("%s: bad:\n%s", name, out)
+		}
+	}
+}
+
+func TestEncodeStructured_golden(t *testing.T) {
+	codes, err := EncodeStructured([]byte(goldenStructured), M, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(codes) != 3 {
+		t.Fatalf("bad: %d", len(codes))
+	}
+
+	// The reference encoder does not choose a mask, symbol i uses mask i.
+	for i, c := range codes {
+		m := &matrix{size: c.Size, modules: append([]bool(nil), c.modules...), function: make([]bool, len(c.modules))}
+		m.drawFunctionPatterns(c.Version)
+		m.applyMask(c.Mask)
+		m.applyMask(i)
+		m.drawFormatBits(c.Level, i)
+
+		name := fmt.Sprintf("structured-%d.txt", i)
+		if out, exp := render(m.size, m.modules), golden(t, name); out != exp {
+			t.Fatalf("%s: bad:\n%s", name, out)
+		}
+	}
+}
+
+// render draws the modules as rows of "#" for dark and "." for light ones.
+func render(size int, modules []bool) string {
+	var b strings.Builder
+	for i, dark := range modules {
+		if dark {
+			b.WriteByte('#')
+		} else {
+			b.WriteByte('.')
+		}
+		if i%size == size-1 {
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+func golden(t *testing.T, name string) string {
+	t.Helper()
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return string(data)
+}
+
+// scan reads the data back from a symbol. It verifies the format information
+// and the error correction codewords but does not correct errors.
+func scan(t *testing.T, c *Code) (string, *structuredAppend) {
+	t.Helper()
+
+	// Read the first copy of the format information and check its BCH code.
+	var bits int
+	for i := 0; i <= 5; i++ {
+		bits |= b2i(c.Black(8, i)) << uint(i)
+	}
+	bits |= b2i(c.Black(8, 7))<<6 | b2i(c.Black(8, 8))<<7 | b2i(c.Black(7, 8))<<8
+	for i := 9; i < 15; i++ {
+		bits |= b2i(c.Black(14-i, 8)) << uint(i)
+	}
+	bits ^= 0x5412
+	if bits>>10 != c.Level.formatBits()<<3|c.Mask {
+		t.Fatalf("bad format information: %05b", bits>>10)
+	}
+	for i := 14; i >= 10; i-- {
+		if bits>>uint(i)&1 == 1 {
+			bits ^= 0x537 << uint(i-10)
+		}
+	}
+	if bits != 0 {
+		t.Fatalf("bad format information checksum")
+	}
+	if !c.Black(8, c.Size-8) {
+		t.Fatalf("missing dark module")
+	}
+
+	// Unmask and read the codewords in placement order.
+	m := &matrix{size: c.Size, modules: append([]bool(nil), c.modules...), function: make([]bool, len(c.modules))}
+	m.drawFunctionPatterns(c.Version)
+	m.applyMask(c.Mask)
+	raw := make([]byte, numRawDataModules(c.Version)/8)
+	i := 0
+	for right := m.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < m.size; vert++ {
+			y := vert
+			if upward {
+				y = m.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if m.function[y*m.size+x] || i >= len(raw)*8 {
+					continue
+				}
+				if m.modules[y*m.size+x] {
+					raw[i>>3] |= 0x80 >> uint(i&7)
+				}
+				i++
+			}
+		}
+	}
+
+	// De-interleave the blocks and verify their error correction codewords.
+	numBlocks := numErrorCorrectionBlocks[c.Level][c.Version]
+	eccLen := eccCodewordsPerBlock[c.Level][c.Version]
+	numShort := numBlocks - len(raw)%numBlocks
+	shortLen := len(raw) / numBlocks
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := 0; i <= shortLen-eccLen; i++ {
+		for j := range blocks {
+			if i < shortLen-eccLen || j >= numShort {
+				blocks[j] = append(blocks[j], raw[k])
+				k++
+			}
+		}
+	}
+	var data []byte
+	for _, b := range blocks {
+		data = append(data, b...)
+	}
+	for i := 0; i < eccLen; i++ {
+		for j := range blocks {
+			blocks[j] = append(blocks[j], raw[k])
+			k++
+		}
+	}
+	divisor := rsDivisor(eccLen)
+	for j, b := range blocks {
+		n := len(b) - eccLen
+		if !bytes.Equal(rsRemainder(b[:n], divisor), b[n:]) {
+			t.Fatalf("bad error correction in block %d", j)
+		}
+	}
+
+	// Parse the segments.
+	r := bitReader{data: data}
+	var sa *structuredAppend
+	var out []byte
+	for r.remaining() >= 4 {
+		switch r.read(4) {
+		case 0x0:
+			return string(out), sa
+		case 0x3:
+			sa = &structuredAppend{index: r.read(4), total: r.read(4) + 1, parity: byte(r.read(8))}
+		case 0x4:
+			n := r.read(modeByte.countBitsFor(c.Version))
+			for ; n > 0; n-- {
+				out = append(out, byte(r.read(8)))
+			}
+		case 0x2:
+			n := r.read(modeAlnum.countBitsFor(c.Version))
+			for ; n > 1; n -= 2 {
+				v := r.read(11)
+				out = append(out, alnumChars[v/45], alnumChars[v%45])
+			}
+			if n == 1 {
+				out = append(out, alnumChars[r.read(6)])
+			}
+		default:
+			t.Fatalf("bad mode")
+		}
+	}
+	return string(out), sa
+}
+
+type bitReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *bitReader) remaining() int {
+	return 8*len(r.data) - r.pos
+}
+
+func (r *bitReader) read(n int) int {
+	v := 0
+	for ; n > 0; n-- {
+		v = v<<1 | int(r.data[r.pos>>3]>>uint(7-r.pos&7)&1)
+		r.pos++
+	}
+	return v
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
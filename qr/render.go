@@ -0,0 +1,103 @@
+package qr
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+)
+
+// quietZone is the width of the light border around a symbol in modules.
+const quietZone = 4
+
+// Image returns the symbol including its quiet zone with each module drawn as
+// a square of scale by scale pixels.
+func (c *Code) Image(scale int) image.Image {
+	if scale < 1 {
+		scale = 1
+	}
+	n := (c.Size + 2*quietZone) * scale
+	img := image.NewPaletted(image.Rect(0, 0, n, n), color.Palette{color.White, color.Black})
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			if c.Black(x/scale-quietZone, y/scale-quietZone) {
+				img.Pix[y*img.Stride+x] = 1
+			}
+		}
+	}
+	return img
+}
+
+// PNG returns the symbol as PNG image with each module drawn as a square of
+// scale by scale pixels.
+func (c *Code) PNG(scale int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, c.Image(scale)); nil != err {
+		return nil, fmt.Errorf("failed to encode png: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG returns the symbol as SVG image with a size of scale pixels per module.
+// The image scales without loss to any other size.
+func (c *Code) SVG(scale int) []byte {
+	if scale < 1 {
+		scale = 1
+	}
+	n := c.Size + 2*quietZone
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" version="1.1" viewBox="0 0 %d %d" width="%d" height="%d" shape-rendering="crispEdges">`, n, n, n*scale, n*scale)
+	buf.WriteString("\n")
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#ffffff"/>`, n, n)
+	buf.WriteString("\n")
+	buf.WriteString(`<path fill="#000000" d="`)
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.Black(x, y) {
+				continue
+			}
+			// Merge horizontal runs of dark modules into one rectangle.
+			w := 1
+			for c.Black(x+w, y) {
+				w++
+			}
+			fmt.Fprintf(&buf, "M%d,%dh%dv1h-%dz", x+quietZone, y+quietZone, w, w)
+			x += w - 1
+		}
+	}
+	buf.WriteString(`"/>`)
+	buf.WriteString("\n</svg>\n")
+	return buf.Bytes()
+}
+
+// Terminal returns the symbol as lines of Unicode block characters, each
+// covering two rows of modules. Dark modules are drawn as blocks, which suits
+// dark text on a light background. If invert is set, light modules are drawn
+// as blocks instead for light text on a dark background.
+func (c *Code) Terminal(invert bool) string {
+	var sb strings.Builder
+	for y := -quietZone; y < c.Size+quietZone; y += 2 {
+		for x := -quietZone; x < c.Size+quietZone; x++ {
+			top, bottom := c.Black(x, y) != invert, c.Black(x, y+1) != invert
+			// The row after the last one belongs to the quiet zone.
+			if y+1 >= c.Size+quietZone {
+				bottom = invert
+			}
+			switch {
+			case top && bottom:
+				sb.WriteString("█")
+			case top:
+				sb.WriteString("▀")
+			case bottom:
+				sb.WriteString("▄")
+			default:
+				sb.WriteString(" ")
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,89 @@
+package qr
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/png"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func testCode(t *testing.T) *Code {
+	c, err := Encode([]byte("HELLO WORLD"), Q)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return c
+}
+
+func TestCode_PNG(t *testing.T) {
+	c := testCode(t)
+	data, err := c.PNG(3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	n := (c.Size + 2*quietZone) * 3
+	if b := img.Bounds(); b.Dx() != n || b.Dy() != n {
+		t.Fatalf("bad: %v", b)
+	}
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			r, _, _, _ := img.At(x, y).RGBA()
+			if (r == 0) != c.Black(x/3-quietZone, y/3-quietZone) {
+				t.Fatalf("bad pixel %d,%d", x, y)
+			}
+		}
+	}
+}
+
+func TestCode_SVG(t *testing.T) {
+	c := testCode(t)
+	data := c.SVG(4)
+
+	var svg struct {
+		Width string `xml:"width,attr"`
+		Path  struct {
+			D string `xml:"d,attr"`
+		} `xml:"path"`
+	}
+	if err := xml.Unmarshal(data, &svg); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if svg.Width != "116" {
+		t.Fatalf("bad: %s", svg.Width)
+	}
+	// The top left finder pattern starts with a run of seven dark modules.
+	if !strings.HasPrefix(svg.Path.D, "M4,4h7v1h-7z") {
+		t.Fatalf("bad: %s", svg.Path.D)
+	}
+}
+
+func TestCode_Terminal(t *testing.T) {
+	c := testCode(t)
+	for _, invert := range []bool{false, true} {
+		lines := strings.Split(strings.TrimSuffix(c.Terminal(invert), "\n"), "\n")
+		n := c.Size + 2*quietZone
+		if len(lines) != (n+1)/2 {
+			t.Fatalf("bad: %d", len(lines))
+		}
+		for _, l := range lines {
+			if utf8.RuneCountInString(l) != n {
+				t.Fatalf("bad: %q", l)
+			}
+		}
+		// The third line holds the first two rows of the finder pattern.
+		exp := "█▀▀▀▀▀█"
+		if invert {
+			exp = " ▄▄▄▄▄ "
+		}
+		if line := []rune(lines[2]); string(line[quietZone:quietZone+7]) != exp {
+			t.Fatalf("bad: %q", lines[2])
+		}
+	}
+}
@@ -0,0 +1,97 @@
+//go:build ignore
+
+// generate writes the golden symbols of the qr package tests using the Go port
+// of the ZXing encoder and, for structured append which ZXing does not
+// support, rsc.io/qr/coding as reference encoders.
+package main
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"github.com/makiuchi-d/gozxing/qrcode/decoder"
+	"github.com/makiuchi-d/gozxing/qrcode/encoder"
+	"rsc.io/qr/coding"
+)
+
+// byteData is encoded at every error correction level.
+const byteData = "https://github.com/corvus-ch/shamir/blob/master/README.md#shamirs-secret-sharing"
+
+// structuredData is spread over structuredSymbols symbols of version
+// structuredVersion at level M.
+const structuredData = "Split a secret into parts, a threshold of which recover the secret."
+
+const (
+	structuredSymbols = 3
+	structuredVersion = 2
+)
+
+// structuredAppend is the header of a symbol of a structured append sequence.
+type structuredAppend struct {
+	index, total int
+	parity       byte
+}
+
+func (sa structuredAppend) Check() error              { return nil }
+func (sa structuredAppend) Bits(v coding.Version) int { return 20 }
+func (sa structuredAppend) Encode(b *coding.Bits, v coding.Version) {
+	b.Write(0x3, 4)
+	b.Write(uint(sa.index), 4)
+	b.Write(uint(sa.total-1), 4)
+	b.Write(uint(sa.parity), 8)
+}
+
+func write(name string, size int, black func(x, y int) bool) {
+	var sb strings.Builder
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if black(x, y) {
+				sb.WriteByte('#')
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	if err := os.WriteFile(name, []byte(sb.String()), 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func main() {
+	levels := []decoder.ErrorCorrectionLevel{
+		decoder.ErrorCorrectionLevel_L,
+		decoder.ErrorCorrectionLevel_M,
+		decoder.ErrorCorrectionLevel_Q,
+		decoder.ErrorCorrectionLevel_H,
+	}
+	for i, level := range levels {
+		q, err := encoder.Encoder_encodeWithoutHint(byteData, level)
+		if err != nil {
+			log.Fatal(err)
+		}
+		m := q.GetMatrix()
+		write("byte-"+"LMQH"[i:i+1]+".txt", m.GetWidth(), func(x, y int) bool { return m.Get(x, y) == 1 })
+	}
+
+	// The reference encoder does not choose a mask, symbol i uses mask i.
+	data := []byte(structuredData)
+	var parity byte
+	for _, b := range data {
+		parity ^= b
+	}
+	n := structuredSymbols
+	for i := 0; i < n; i++ {
+		p, err := coding.NewPlan(structuredVersion, coding.M, coding.Mask(i))
+		if err != nil {
+			log.Fatal(err)
+		}
+		chunk := data[i*len(data)/n : (i+1)*len(data)/n]
+		c, err := p.Encode(structuredAppend{i, n, parity}, coding.String(chunk))
+		if err != nil {
+			log.Fatal(err)
+		}
+		write("structured-"+string(rune('0'+i))+".txt", c.Size, c.Black)
+	}
+}
@@ -0,0 +1,248 @@
+package shamir
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Refreshing renews the shares of a secret without reconstructing it or
+// changing the secret, the threshold or the x coordinates, so that shares
+// leaked before the refresh can not be combined with shares obtained after it.
+//
+// Every holder splits a zero secret among all holders using the threshold of
+// the shares and hands out the parts as updates. Each holder adds the updates
+// it receives, including its own, to its share. As the updates lie on
+// polynomials with a zero constant term, so does their sum, and the secret is
+// unchanged. No party ever learns more than its own share.
+//
+// The refreshed shares get a new set identifier, so combining them with old
+// shares fails with a *SetMismatchError, even though they use the same x
+// coordinates. The salt is kept and the tag refreshed along with the value,
+// so the secret can still be verified.
+
+// RefreshUpdate returns the updates of the holder of s for a refresh of the
+// shares of the holders, given by their x coordinates including the one of s.
+// The holders must be at least as many as the threshold of the share.
+//
+// The identifier of the refreshed share set must be given using WithSetID, as
+// all holders must agree on it. The returned updates are ordered by x
+// coordinate. Each one must be handed to the holder of that x coordinate, who
+// applies the updates of all holders using Share.ApplyRefresh.
+func (s *Share) RefreshUpdate(holders []byte, opts ...Option) ([]*Share, error) {
+	h := s.header()
+	if len(holders) < h.threshold {
+		return nil, ErrNotEnoughShares
+	}
+	if bytes.IndexByte(holders, s.X) < 0 {
+		return nil, fmt.Errorf("x coordinate %d is not among the holders", s.X)
+	}
+	cfg := newConfig(opts)
+	if nil == cfg.setID {
+		return nil, fmt.Errorf("new set id must be given")
+	}
+	if *cfg.setID == s.SetID {
+		return nil, fmt.Errorf("new set id must differ from the old one")
+	}
+	if nil != s.Tag && len(s.Tag) != shareTagSize {
+		return nil, fmt.Errorf("tag must be %d bytes long", shareTagSize)
+	}
+
+	length := len(s.Value) + len(s.Tag)
+	if length < 1 {
+		return nil, fmt.Errorf("share must be at least one byte long")
+	}
+	opts = append(opts[:len(opts):len(opts)], WithCoordinates(holders...), WithField(h.field))
+	parts, err := Split(make([]byte, length), len(holders), h.threshold, opts...)
+	if nil != err {
+		return nil, err
+	}
+
+	out := make([]*Share, 0, len(parts))
+	for x, value := range parts {
+		u := &Share{
+			X:         x,
+			Threshold: s.Threshold,
+			SetID:     *cfg.setID,
+			Field:     s.Field,
+			Value:     value[:len(s.Value):len(s.Value)],
+			Salt:      s.Salt,
+		}
+		if nil != s.Tag {
+			u.Tag = value[len(s.Value):]
+		}
+		out = append(out, u)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].X < out[j].X })
+
+	return out, nil
+}
+
+// checkUpdate ensures the update described by u belongs to the refresh of the
+// share described by h, of which first is the first update.
+func checkUpdate(h, first, u header) error {
+	if u.x != h.x {
+		return fmt.Errorf("update for x coordinate %d cannot be applied to share %d", u.x, h.x)
+	}
+	if u.setID == h.setID {
+		return fmt.Errorf("new set id must differ from the old one")
+	}
+	if u.setID != first.setID {
+		return fmt.Errorf("updates must be for the same share set")
+	}
+	if u.version != h.version || u.threshold != h.threshold || u.field.Poly() != h.field.Poly() || u.salt != h.salt {
+		return fmt.Errorf("updates must have the same format, threshold, field and salt as the share")
+	}
+	if 0 != h.length && 0 != u.length && u.length != h.length {
+		return fmt.Errorf("updates must be as long as the share")
+	}
+	return nil
+}
+
+// ApplyRefresh adds the updates received from the holders to s, returning the
+// refreshed share. The updates of all holders passed to RefreshUpdate are
+// required; any update left out results in a share inconsistent with the
+// others.
+func (s *Share) ApplyRefresh(updates ...*Share) (*Share, error) {
+	h := s.header()
+	if len(updates) < h.threshold {
+		return nil, fmt.Errorf("at least %d updates are required", h.threshold)
+	}
+	if nil != s.Tag && len(s.Tag) != shareTagSize {
+		return nil, fmt.Errorf("tag must be %d bytes long", shareTagSize)
+	}
+
+	first := updates[0].header()
+	value := append(append(make([]byte, 0, len(s.Value)+len(s.Tag)), s.Value...), s.Tag...)
+	for _, u := range updates {
+		if err := checkUpdate(h, first, u.header()); nil != err {
+			return nil, err
+		}
+		if len(u.Value) != len(s.Value) || len(u.Tag) != len(s.Tag) {
+			return nil, fmt.Errorf("updates must be as long as the share")
+		}
+		for i, y := range u.Value {
+			value[i] ^= y
+		}
+		for i, y := range u.Tag {
+			value[len(s.Value)+i] ^= y
+		}
+	}
+
+	out := &Share{
+		X:         s.X,
+		Threshold: s.Threshold,
+		SetID:     first.setID,
+		Field:     s.Field,
+		Value:     value[:len(s.Value):len(s.Value)],
+		Salt:      s.Salt,
+	}
+	if nil != s.Tag {
+		out.Tag = value[len(s.Value):]
+	}
+	return out, nil
+}
+
+// RefreshShares refreshes the shares of all holders, returning the new shares
+// ordered by x coordinate. The shares are checked to belong to the same share
+// set and to be at least as many as their threshold. Any share left out can
+// not be combined with the refreshed ones. Unless given using WithSetID, the
+// new set identifier is picked at random.
+//
+// RefreshShares runs Share.RefreshUpdate and Share.ApplyRefresh for every
+// share within a single process. To keep the shares apart, run these methods
+// on the machines of the respective holders instead.
+func RefreshShares(shares []*Share, opts ...Option) ([]*Share, error) {
+	hs := make([]header, len(shares))
+	holders := make([]byte, len(shares))
+	for i, s := range shares {
+		hs[i] = s.header()
+		holders[i] = s.X
+	}
+	if err := checkHeaders(hs); nil != err {
+		return nil, err
+	}
+
+	opts, err := reshareSetID(opts)
+	if nil != err {
+		return nil, err
+	}
+
+	received := make(map[byte][]*Share, len(shares))
+	for _, s := range shares {
+		updates, err := s.RefreshUpdate(holders, opts...)
+		if nil != err {
+			return nil, err
+		}
+		for _, u := range updates {
+			received[u.X] = append(received[u.X], u)
+		}
+	}
+
+	out := make([]*Share, 0, len(shares))
+	for _, s := range shares {
+		r, err := s.ApplyRefresh(received[s.X]...)
+		if nil != err {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].X < out[j].X })
+
+	return out, nil
+}
+
+// NewRefreshShareReader works like Share.ApplyRefresh but reads the share and
+// the updates in their self-describing form, as written by NewShareWriter or
+// Share.MarshalBinary, and returns a reader for the refreshed share in the
+// same form. The share may be of unknown length, its value is then checked to
+// be as long as the updates while reading.
+//
+// The headers of the share and all updates are read before
+// NewRefreshShareReader returns.
+func NewRefreshShareReader(share io.Reader, updates ...io.Reader) (io.Reader, error) {
+	h, err := readHeader(share)
+	if nil != err {
+		return nil, err
+	}
+	if len(updates) < h.threshold {
+		return nil, fmt.Errorf("at least %d updates are required", h.threshold)
+	}
+	if h.length != 0 {
+		share = &lengthReader{r: share, n: h.length + uint64(h.tagSize())}
+	}
+
+	readers := []io.Reader{share}
+	var first header
+	for i, r := range updates {
+		u, err := readHeader(r)
+		if nil != err {
+			return nil, err
+		}
+		if i == 0 {
+			first = u
+		}
+		if err := checkUpdate(h, first, u); nil != err {
+			return nil, err
+		}
+		if u.length != 0 {
+			r = &lengthReader{r: r, n: u.length + uint64(u.tagSize())}
+		}
+		readers = append(readers, r)
+	}
+
+	// The refreshed share is the sum of the share and the updates.
+	weights := make([]byte, len(readers))
+	for i := range weights {
+		weights[i] = 1
+	}
+	sum := &reader{field: h.field, readers: readers, weights: weights}
+
+	out := h
+	out.setID = first.setID
+	if out.length == 0 {
+		out.length = first.length
+	}
+	return io.MultiReader(bytes.NewReader(out.marshal()), sum), nil
+}
@@ -0,0 +1,299 @@
+package shamir
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+func TestRefreshShares(t *testing.T) {
+	secret := []byte("test")
+
+	for _, f := range []*Field{DefaultField, AESField} {
+		old, err := SplitShares(secret, 5, 3, WithField(f))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		out, err := RefreshShares(old)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if len(out) != 5 || out[0].Threshold != 3 || out[0].SetID == old[0].SetID || out[0].Salt != old[0].Salt || out[0].Field != old[0].Field {
+			t.Fatalf("bad: %v", out[0])
+		}
+		for i, s := range out {
+			if s.X != old[i].X || bytes.Equal(s.Value, old[i].Value) {
+				t.Fatalf("bad: %v", s)
+			}
+		}
+
+		recomb, err := CombineShares(out[1:4])
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !bytes.Equal(recomb, secret) {
+			t.Fatalf("bad: %v %v", recomb, secret)
+		}
+
+		// Old and new shares use the same x coordinates, only the set
+		// identifier tells them apart.
+		if _, err := CombineShares([]*Share{old[0], out[1], out[2]}); err == nil {
+			t.Fatalf("expect error")
+		} else if e, ok := err.(*SetMismatchError); !ok || e.SetID != out[0].SetID || !bytes.Equal(e.X, []byte{old[0].X}) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestRefreshShares_setID(t *testing.T) {
+	old, err := SplitShares([]byte("test"), 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := RefreshShares(old, WithSetID(SetID{42}))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out[0].SetID != (SetID{42}) {
+		t.Fatalf("bad: %v", out[0])
+	}
+
+	if _, err := RefreshShares(old, WithSetID(old[0].SetID)); err == nil {
+		t.Fatalf("expect error")
+	}
+	if _, err := RefreshShares(old[:1]); err != ErrNotEnoughShares {
+		t.Fatalf("bad: %v", err)
+	}
+	if _, err := RefreshShares([]*Share{old[0], out[1]}); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestShare_RefreshUpdate(t *testing.T) {
+	secret := []byte("test")
+
+	old, err := SplitShares(secret, 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The updates travel in their binary form.
+	holders := []byte{old[0].X, old[1].X, old[2].X}
+	received := map[byte][]*Share{}
+	for _, s := range old {
+		updates, err := s.RefreshUpdate(holders, WithSetID(SetID{42}))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if len(updates) != 3 {
+			t.Fatalf("bad: %v", updates)
+		}
+		for _, u := range updates {
+			data, err := u.MarshalBinary()
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			var in Share
+			if err := in.UnmarshalBinary(data); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			received[in.X] = append(received[in.X], &in)
+		}
+	}
+
+	var out []*Share
+	for _, s := range old {
+		r, err := s.ApplyRefresh(received[s.X]...)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		out = append(out, r)
+	}
+
+	recomb, err := CombineShares(out[:2])
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recomb, secret) {
+		t.Fatalf("bad: %v %v", recomb, secret)
+	}
+	if out[0].SetID != (SetID{42}) || out[0].Salt != old[0].Salt {
+		t.Fatalf("bad: %v", out[0])
+	}
+
+	// A share missing an update is inconsistent with the others.
+	r, err := old[0].ApplyRefresh(received[old[0].X][:2]...)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := CombineShares([]*Share{r, out[1]}); err != ErrVerification {
+		t.Fatalf("bad: %v", err)
+	}
+
+	// The new set identifier must be given and differ from the old one.
+	if _, err := old[0].RefreshUpdate(holders); err == nil {
+		t.Fatalf("expect error")
+	}
+	if _, err := old[0].RefreshUpdate(holders, WithSetID(old[0].SetID)); err == nil {
+		t.Fatalf("expect error")
+	}
+	if _, err := old[0].RefreshUpdate(holders[:1], WithSetID(SetID{42})); err != ErrNotEnoughShares {
+		t.Fatalf("bad: %v", err)
+	}
+	if _, err := old[0].RefreshUpdate(holders[1:], WithSetID(SetID{42})); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestShare_ApplyRefresh_invalid(t *testing.T) {
+	old, err := SplitShares([]byte("test"), 3, 2, WithCoordinates(1, 2, 3))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	holders := []byte{1, 2, 3}
+	a, err := old[0].RefreshUpdate(holders, WithSetID(SetID{1}))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	b, err := old[1].RefreshUpdate(holders, WithSetID(SetID{2}))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	other, err := SplitShares([]byte("longer"), 3, 2, WithCoordinates(1, 2, 3))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c, err := other[0].RefreshUpdate(holders, WithSetID(SetID{1}))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	stale := *a[0]
+	stale.SetID = old[0].SetID
+
+	for i, updates := range [][]*Share{
+		{a[0]},
+		{a[0], a[1]},
+		{a[0], b[0]},
+		{a[0], c[0]},
+		{a[0], &stale},
+	} {
+		if _, err := old[0].ApplyRefresh(updates...); err == nil {
+			t.Errorf("%d: expect error", i)
+		}
+	}
+}
+
+func TestRefreshShareReader(t *testing.T) {
+	secret := []byte("test")
+
+	// The share to refresh is streamed and of unknown length.
+	streams := map[byte]*bytes.Buffer{}
+	w, err := NewShareWriter(2, 2, func(x byte) (io.Writer, error) {
+		streams[x] = &bytes.Buffer{}
+		return streams[x], nil
+	}, WithCoordinates(1, 2))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := w.Write(secret); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	old := make([]*Share, 2)
+	for i, x := range []byte{1, 2} {
+		old[i] = &Share{}
+		if err := old[i].UnmarshalBinary(streams[x].Bytes()); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	received := map[byte][]io.Reader{}
+	for _, s := range old {
+		updates, err := s.RefreshUpdate([]byte{1, 2}, WithSetID(SetID{42}))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		for _, u := range updates {
+			data, err := u.MarshalBinary()
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			received[u.X] = append(received[u.X], bytes.NewReader(data))
+		}
+	}
+
+	readers := make([]io.Reader, 0, 2)
+	for _, x := range []byte{1, 2} {
+		r, err := NewRefreshShareReader(streams[x], received[x]...)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		var s Share
+		if err := s.UnmarshalBinary(data); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if s.X != x || s.SetID != (SetID{42}) || bytes.Equal(s.Value, old[x-1].Value) {
+			t.Fatalf("bad: %v", s)
+		}
+		readers = append(readers, bytes.NewReader(data))
+	}
+
+	r, err := NewShareReader(readers...)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	recomb, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recomb, secret) {
+		t.Fatalf("bad: %v %v", recomb, secret)
+	}
+}
+
+func TestRefreshShareReader_invalid(t *testing.T) {
+	old, err := SplitShares([]byte("test"), 2, 2, WithCoordinates(1, 2))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	share, err := old[0].MarshalBinary()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	updates, err := old[0].RefreshUpdate([]byte{1, 2}, WithSetID(SetID{42}))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	data := make([][]byte, len(updates))
+	for i, u := range updates {
+		if data[i], err = u.MarshalBinary(); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	if _, err := NewRefreshShareReader(bytes.NewReader(share), bytes.NewReader(data[0])); err == nil {
+		t.Fatalf("expect error")
+	}
+	// The second update is for the other share.
+	if _, err := NewRefreshShareReader(bytes.NewReader(share), bytes.NewReader(data[0]), bytes.NewReader(data[1])); err == nil {
+		t.Fatalf("expect error")
+	}
+
+	// An update shorter than announced by its header.
+	r, err := NewRefreshShareReader(bytes.NewReader(share), bytes.NewReader(data[0]), bytes.NewReader(data[0][:len(data[0])-1]))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := ioutil.ReadAll(r); err == nil {
+		t.Fatalf("expect error")
+	}
+}
@@ -0,0 +1,318 @@
+package shamir
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Resharing moves a secret to a new share set with a different number of
+// parts, threshold or x coordinates without reconstructing it.
+//
+// The secret is the sum of the old shares weighted by their Lagrange
+// coefficients at x=0. Each old holder taking part splits its own weighted
+// share using the new parameters and hands one sub-share to every new holder.
+// Since splitting is linear, the sum of the sub-shares a new holder receives
+// is its share of the secret. No party ever learns more than its own share.
+//
+// The new x coordinates must be agreed on upfront and given to every old
+// holder using WithCoordinates.
+
+// SubShare computes the contribution of the old share (x, part) to a new share
+// set of `parts` shares, `threshold` of which are required to reconstruct the
+// secret. The holders are the x coordinates of all old shares taking part in
+// the resharing, including x; there must be at least as many of them as the
+// old threshold. The new x coordinates must be given using WithCoordinates.
+//
+// The returned sub-shares are keyed by the new x coordinates. Each one must be
+// handed to the holder of that new share, who combines the sub-shares of all
+// holders using CombineSubShares.
+func SubShare(x byte, part []byte, holders []byte, parts, threshold int, opts ...Option) (map[byte][]byte, error) {
+	cfg := newConfig(opts)
+	if nil == cfg.xs {
+		return nil, fmt.Errorf("new x coordinates must be given")
+	}
+
+	w, err := reshareWeight(cfg.field, x, holders)
+	if nil != err {
+		return nil, err
+	}
+
+	scaled := make([]byte, len(part))
+	defer zero(scaled)
+	for i, y := range part {
+		scaled[i] = cfg.field.Mul(w, y)
+	}
+
+	return Split(scaled, parts, threshold, opts...)
+}
+
+// reshareWeight returns the Lagrange coefficient at x=0 of the share at x
+// within the holders.
+func reshareWeight(f *Field, x byte, holders []byte) (byte, error) {
+	if len(holders) < 2 {
+		return 0, fmt.Errorf("at least two holders are required")
+	}
+	var used [256]bool
+	i := -1
+	for j, h := range holders {
+		if h == 0 {
+			return 0, fmt.Errorf("x coordinate cannot be zero")
+		}
+		if used[h] {
+			return 0, fmt.Errorf("x coordinate %d is not unique", h)
+		}
+		used[h] = true
+		if h == x {
+			i = j
+		}
+	}
+	if i < 0 {
+		return 0, fmt.Errorf("x coordinate %d is not among the holders", x)
+	}
+
+	return f.lagrange(holders, 0)[i], nil
+}
+
+// CombineSubShares computes a new share from the sub-shares handed out by the
+// old holders, keyed by their x coordinates. The sub-shares of all holders
+// passed to SubShare are required.
+func CombineSubShares(subShares map[byte][]byte) ([]byte, error) {
+	if len(subShares) < 2 {
+		return nil, fmt.Errorf("at least two sub-shares are required")
+	}
+
+	var out []byte
+	for _, sub := range subShares {
+		if nil == out {
+			out = make([]byte, len(sub))
+		}
+		if len(sub) != len(out) {
+			return nil, fmt.Errorf("all sub-shares must be the same length")
+		}
+		for i, y := range sub {
+			out[i] ^= y
+		}
+	}
+
+	return out, nil
+}
+
+// Reshare takes at least `threshold` parts of a secret and returns a new set
+// of `parts` shares, `threshold` of which are required to reconstruct the
+// secret. Unless given using WithCoordinates, the new x coordinates are picked
+// at random.
+//
+// Reshare runs SubShare for every old part and CombineSubShares for every new
+// share within a single process. To keep the old parts apart, run these
+// functions on the machines of the respective holders instead.
+//
+// The parts carry nothing to tell the old and the new set apart. Combining
+// old and new parts is not detected and yields a wrong secret. Use
+// ReshareShares to have mixed sets refused.
+func Reshare(old map[byte][]byte, parts, threshold int, opts ...Option) (map[byte][]byte, error) {
+	opts, err := reshareCoordinates(parts, opts)
+	if nil != err {
+		return nil, err
+	}
+
+	holders := make([]byte, 0, len(old))
+	for x := range old {
+		holders = append(holders, x)
+	}
+
+	received := make(map[byte]map[byte][]byte, parts)
+	for x, part := range old {
+		subs, err := SubShare(x, part, holders, parts, threshold, opts...)
+		if nil != err {
+			return nil, err
+		}
+		for nx, sub := range subs {
+			if nil == received[nx] {
+				received[nx] = make(map[byte][]byte, len(old))
+			}
+			received[nx][x] = sub
+		}
+	}
+
+	out := make(map[byte][]byte, parts)
+	for nx, subs := range received {
+		if out[nx], err = CombineSubShares(subs); nil != err {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+// reshareCoordinates picks the new x coordinates at random unless they are
+// given in opts already.
+func reshareCoordinates(parts int, opts []Option) ([]Option, error) {
+	cfg := newConfig(opts)
+	if nil != cfg.xs {
+		return opts, nil
+	}
+	if parts < 1 || parts > 255 {
+		return nil, fmt.Errorf("parts must be between 1 and 255")
+	}
+	xs, err := randomCoordinates(cfg.rand, parts)
+	if nil != err {
+		return nil, err
+	}
+	return append(opts[:len(opts):len(opts)], WithCoordinates(xs...)), nil
+}
+
+// reshareSetID picks the new set identifier at random unless it is given in
+// opts already.
+func reshareSetID(opts []Option) ([]Option, error) {
+	cfg := newConfig(opts)
+	if nil != cfg.setID {
+		return opts, nil
+	}
+	id, err := newSetID(cfg)
+	if nil != err {
+		return nil, err
+	}
+	return append(opts[:len(opts):len(opts)], WithSetID(id)), nil
+}
+
+// SubShare works like the function of the same name but takes and returns
+// shares in their self-describing form. The holders must be at least as many
+// as the threshold of the share.
+//
+// Besides the new x coordinates, the identifier of the new share set must be
+// given using WithSetID. Combining old and new shares then fails with a
+// *SetMismatchError. The sub-shares keep the salt of the digest of the
+// secret, which is reshared along with it.
+func (s *Share) SubShare(holders []byte, parts, threshold int, opts ...Option) ([]*Share, error) {
+	h := s.header()
+	if len(holders) < h.threshold {
+		return nil, ErrNotEnoughShares
+	}
+	cfg := newConfig(opts)
+	if nil == cfg.setID {
+		return nil, fmt.Errorf("new set id must be given")
+	}
+	if *cfg.setID == s.SetID {
+		return nil, fmt.Errorf("new set id must differ from the old one")
+	}
+	if nil != s.Tag && len(s.Tag) != shareTagSize {
+		return nil, fmt.Errorf("tag must be %d bytes long", shareTagSize)
+	}
+
+	part := append(append(make([]byte, 0, len(s.Value)+len(s.Tag)), s.Value...), s.Tag...)
+	defer zero(part)
+	subs, err := SubShare(s.X, part, holders, parts, threshold, append(opts[:len(opts):len(opts)], WithField(h.field))...)
+	if nil != err {
+		return nil, err
+	}
+
+	out := make([]*Share, 0, len(subs))
+	for x, value := range subs {
+		sub := &Share{
+			X:         x,
+			Threshold: threshold,
+			SetID:     *cfg.setID,
+			Field:     s.Field,
+			Value:     value[:len(s.Value):len(s.Value)],
+			Salt:      s.Salt,
+		}
+		if nil != s.Tag {
+			sub.Tag = value[len(s.Value):]
+		}
+		out = append(out, sub)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].X < out[j].X })
+
+	return out, nil
+}
+
+// CombineSubShareSet works like CombineSubShares but takes the sub-shares in
+// their self-describing form, as returned by Share.SubShare. All sub-shares
+// must be for the same new share.
+func CombineSubShareSet(subShares []*Share) (*Share, error) {
+	if len(subShares) < 2 {
+		return nil, fmt.Errorf("at least two sub-shares are required")
+	}
+
+	first := subShares[0]
+	h := first.header()
+	parts := make(map[byte][]byte, len(subShares))
+	for i, sub := range subShares {
+		sh := sub.header()
+		if sh.x != h.x || sh.setID != h.setID || sh.threshold != h.threshold || sh.field.Poly() != h.field.Poly() || sh.version != h.version || sh.salt != h.salt {
+			return nil, fmt.Errorf("sub-shares must be for the same share")
+		}
+		if len(sub.Value) != len(first.Value) {
+			return nil, fmt.Errorf("all sub-shares must be the same length")
+		}
+		// The sub-shares are summed up, their keys only need to be
+		// distinct.
+		parts[byte(i)] = append(append([]byte(nil), sub.Value...), sub.Tag...)
+	}
+
+	value, err := CombineSubShares(parts)
+	if nil != err {
+		return nil, err
+	}
+
+	out := &Share{
+		X:         first.X,
+		Threshold: first.Threshold,
+		SetID:     first.SetID,
+		Field:     first.Field,
+		Value:     value[:len(first.Value):len(first.Value)],
+		Salt:      first.Salt,
+	}
+	if nil != first.Tag {
+		out.Tag = value[len(first.Value):]
+	}
+	return out, nil
+}
+
+// ReshareShares works like Reshare but takes and returns shares in their
+// self-describing form, ordered by x coordinate. The old shares are checked
+// to belong to the same share set and to be at least as many as their
+// threshold. Unless given using WithSetID, the new set identifier is picked
+// at random.
+func ReshareShares(shares []*Share, parts, threshold int, opts ...Option) ([]*Share, error) {
+	hs := make([]header, len(shares))
+	holders := make([]byte, len(shares))
+	for i, s := range shares {
+		hs[i] = s.header()
+		holders[i] = s.X
+	}
+	if err := checkHeaders(hs); nil != err {
+		return nil, err
+	}
+
+	opts, err := reshareCoordinates(parts, opts)
+	if nil != err {
+		return nil, err
+	}
+	if opts, err = reshareSetID(opts); nil != err {
+		return nil, err
+	}
+
+	received := make(map[byte][]*Share, parts)
+	for _, s := range shares {
+		subs, err := s.SubShare(holders, parts, threshold, opts...)
+		if nil != err {
+			return nil, err
+		}
+		for _, sub := range subs {
+			received[sub.X] = append(received[sub.X], sub)
+		}
+	}
+
+	out := make([]*Share, 0, parts)
+	for _, subs := range received {
+		s, err := CombineSubShareSet(subs)
+		if nil != err {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].X < out[j].X })
+
+	return out, nil
+}
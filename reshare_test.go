@@ -0,0 +1,318 @@
+package shamir
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReshare(t *testing.T) {
+	secret := []byte("test")
+
+	old, err := Split(secret, 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	// Use any two of the old parts.
+	for x := range old {
+		delete(old, x)
+		break
+	}
+
+	out, err := Reshare(old, 7, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(out) != 7 {
+		t.Fatalf("bad: %v", out)
+	}
+
+	parts := make(map[byte][]byte, 3)
+	for x, part := range out {
+		parts[x] = part
+		if len(parts) == 3 {
+			break
+		}
+	}
+	recomb, err := Combine(parts)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recomb, secret) {
+		t.Fatalf("bad: %v %v", recomb, secret)
+	}
+}
+
+func TestReshare_coordinates(t *testing.T) {
+	secret := []byte("test")
+
+	old, err := Split(secret, 5, 3, WithField(AESField))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := Reshare(old, 2, 2, WithField(AESField), WithCoordinates(1, 2))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, ok := out[1]; !ok || len(out) != 2 {
+		t.Fatalf("bad: %v", out)
+	}
+	recomb, err := Combine(out, WithField(AESField))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recomb, secret) {
+		t.Fatalf("bad: %v %v", recomb, secret)
+	}
+}
+
+func TestSubShare(t *testing.T) {
+	secret := []byte("test")
+
+	old, err := Split(secret, 3, 3, WithCoordinates(1, 2, 3))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Each old holder runs SubShare on its own and sends the sub-shares to
+	// the new holders.
+	holders := []byte{1, 2, 3}
+	received := map[byte]map[byte][]byte{}
+	for _, x := range holders {
+		subs, err := SubShare(x, old[x], holders, 4, 2, WithCoordinates(10, 20, 30, 40))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		for nx, sub := range subs {
+			if received[nx] == nil {
+				received[nx] = map[byte][]byte{}
+			}
+			received[nx][x] = sub
+		}
+	}
+
+	out := make(map[byte][]byte, 4)
+	for nx, subs := range received {
+		if out[nx], err = CombineSubShares(subs); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	recomb, err := Combine(map[byte][]byte{20: out[20], 40: out[40]})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recomb, secret) {
+		t.Fatalf("bad: %v %v", recomb, secret)
+	}
+}
+
+func TestSubShare_invalid(t *testing.T) {
+	part := []byte("test")
+	xs := WithCoordinates(10, 20, 30)
+
+	if _, err := SubShare(1, part, []byte{1, 2}, 3, 2); err == nil {
+		t.Fatalf("expect error")
+	}
+	if _, err := SubShare(1, part, []byte{2, 3}, 3, 2, xs); err == nil {
+		t.Fatalf("expect error")
+	}
+	if _, err := SubShare(1, part, []byte{1, 1}, 3, 2, xs); err == nil {
+		t.Fatalf("expect error")
+	}
+	if _, err := SubShare(1, part, []byte{1, 0}, 3, 2, xs); err == nil {
+		t.Fatalf("expect error")
+	}
+	if _, err := SubShare(1, part, []byte{1}, 3, 2, xs); err == nil {
+		t.Fatalf("expect error")
+	}
+	if _, err := SubShare(1, part, []byte{1, 2}, 3, 4, xs); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestCombineSubShares_invalid(t *testing.T) {
+	if _, err := CombineSubShares(map[byte][]byte{1: {1}}); err == nil {
+		t.Fatalf("expect error")
+	}
+	if _, err := CombineSubShares(map[byte][]byte{1: {1}, 2: {1, 2}}); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestReshareShares(t *testing.T) {
+	secret := []byte("test")
+
+	for _, f := range []*Field{DefaultField, AESField} {
+		old, err := SplitShares(secret, 3, 2, WithField(f))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		out, err := ReshareShares(old[1:], 7, 3)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if len(out) != 7 || out[0].Threshold != 3 || out[0].SetID == old[0].SetID || out[0].Salt != old[0].Salt || out[0].Field != old[0].Field {
+			t.Fatalf("bad: %v", out[0])
+		}
+
+		recomb, err := CombineShares(out[2:5])
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !bytes.Equal(recomb, secret) {
+			t.Fatalf("bad: %v %v", recomb, secret)
+		}
+
+		if _, err := CombineShares(out[:2]); err != ErrNotEnoughShares {
+			t.Fatalf("bad: %v", err)
+		}
+		// The x coordinates are random, pick new shares not sharing the one
+		// of the old share.
+		mixed := []*Share{old[0]}
+		for _, s := range out {
+			if s.X != old[0].X && len(mixed) < 3 {
+				mixed = append(mixed, s)
+			}
+		}
+		if _, err := CombineShares(mixed); err == nil {
+			t.Fatalf("expect error")
+		} else if e, ok := err.(*SetMismatchError); !ok || e.SetID != out[0].SetID || !bytes.Equal(e.X, []byte{old[0].X}) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestReshareShares_mixed(t *testing.T) {
+	secret := []byte("test")
+
+	// Shares without a tag can not be verified, so only the set identifier
+	// tells the old and the new set apart.
+	parts, err := Split(secret, 3, 2, WithCoordinates(1, 2, 3))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	old := make([]*Share, 0, len(parts))
+	for _, x := range []byte{1, 2, 3} {
+		old = append(old, &Share{X: x, Threshold: 2, SetID: SetID{1}, Value: parts[x]})
+	}
+
+	out, err := ReshareShares(old[:2], 3, 2, WithCoordinates(1, 2, 3))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	recomb, err := CombineShares(out[1:])
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recomb, secret) {
+		t.Fatalf("bad: %v %v", recomb, secret)
+	}
+
+	for _, mixed := range [][]*Share{
+		{old[0], out[1]},
+		{out[0], old[1], out[2]},
+	} {
+		if _, err := CombineShares(mixed); err == nil {
+			t.Fatalf("expect error")
+		} else if _, ok := err.(*SetMismatchError); !ok {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestReshareShares_notEnoughShares(t *testing.T) {
+	old, err := SplitShares([]byte("test"), 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := ReshareShares(old[:1], 3, 2); err != ErrNotEnoughShares {
+		t.Fatalf("bad: %v", err)
+	}
+	if _, err := old[0].SubShare([]byte{old[0].X}, 3, 2, WithCoordinates(1, 2, 3), WithSetID(SetID{1})); err != ErrNotEnoughShares {
+		t.Fatalf("bad: %v", err)
+	}
+}
+
+func TestShare_SubShare(t *testing.T) {
+	secret := []byte("test")
+
+	old, err := SplitShares(secret, 2, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The sub-shares travel in their binary form.
+	holders := []byte{old[0].X, old[1].X}
+	received := map[byte][]*Share{}
+	for _, s := range old {
+		subs, err := s.SubShare(holders, 3, 3, WithCoordinates(1, 2, 3), WithSetID(SetID{42}))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		for _, sub := range subs {
+			data, err := sub.MarshalBinary()
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			var in Share
+			if err := in.UnmarshalBinary(data); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			received[in.X] = append(received[in.X], &in)
+		}
+	}
+
+	var out []*Share
+	for _, x := range []byte{1, 2, 3} {
+		s, err := CombineSubShareSet(received[x])
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		out = append(out, s)
+	}
+
+	recomb, err := CombineShares(out)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recomb, secret) {
+		t.Fatalf("bad: %v %v", recomb, secret)
+	}
+
+	if out[0].SetID != (SetID{42}) || out[0].Salt != old[0].Salt {
+		t.Fatalf("bad: %v", out[0])
+	}
+
+	// A single sub-share is not a share.
+	out[0] = received[1][0]
+	if _, err := CombineShares(out); err != ErrVerification {
+		t.Fatalf("bad: %v", err)
+	}
+
+	// The new set identifier must be given and differ from the old one.
+	if _, err := old[0].SubShare(holders, 3, 3, WithCoordinates(1, 2, 3)); err == nil {
+		t.Fatalf("expect error")
+	}
+	if _, err := old[0].SubShare(holders, 3, 3, WithCoordinates(1, 2, 3), WithSetID(old[0].SetID)); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestCombineSubShareSet_invalid(t *testing.T) {
+	old, err := SplitShares([]byte("test"), 2, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	subs, err := old[0].SubShare([]byte{old[0].X, old[1].X}, 2, 2, WithCoordinates(1, 2), WithSetID(SetID{1}))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := CombineSubShareSet(subs); err == nil {
+		t.Fatalf("expect error")
+	}
+	if _, err := CombineSubShareSet(subs[:1]); err == nil {
+		t.Fatalf("expect error")
+	}
+}
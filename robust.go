@@ -0,0 +1,359 @@
+package shamir
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// ErrTooManyCorrupted is returned when more shares are corrupted than can be
+// corrected with the number of shares available.
+var ErrTooManyCorrupted = errors.New("too many corrupted shares")
+
+// The shares of a secret byte are the values of a polynomial of degree
+// threshold-1, which makes them a Reed-Solomon codeword. Given n shares of
+// which at most (n-threshold)/2 are corrupted, the Berlekamp-Welch algorithm
+// recovers the polynomial and thereby the secret and the corrupted shares.
+type decoder struct {
+	field     *Field
+	xs        []byte
+	threshold int
+	// errors is the maximum number of corrupted shares which can be
+	// corrected.
+	errors int
+	// check holds for each share beyond the first threshold ones the
+	// weights to predict its value from the first threshold shares.
+	check [][]byte
+	// secret holds the weights to compute the secret from the first
+	// threshold shares.
+	secret []byte
+}
+
+func newDecoder(f *Field, xs []byte, threshold int) (*decoder, error) {
+	if threshold < 2 {
+		return nil, fmt.Errorf("threshold must be at least 2")
+	}
+	if len(xs) < threshold {
+		return nil, ErrNotEnoughShares
+	}
+
+	d := decoder{
+		field:     f,
+		xs:        xs,
+		threshold: threshold,
+		errors:    (len(xs) - threshold) / 2,
+		check:     make([][]byte, len(xs)-threshold),
+		secret:    f.lagrange(xs[:threshold], 0),
+	}
+	for i, x := range xs[threshold:] {
+		d.check[i] = f.lagrange(xs[:threshold], x)
+	}
+	return &d, nil
+}
+
+// decode returns the secret byte for the given y values and marks the indices
+// of the corrupted values in bad.
+func (d *decoder) decode(ys []byte, bad []bool) (byte, error) {
+	f := d.field
+
+	// Most of the time nothing is corrupted. This is cheap to check by
+	// predicting all other values from the first threshold ones.
+	consistent := true
+	for i, weights := range d.check {
+		var y byte
+		for j, w := range weights {
+			y = f.Add(y, f.Mul(w, ys[j]))
+		}
+		if y != ys[d.threshold+i] {
+			consistent = false
+			break
+		}
+	}
+	if consistent {
+		var secret byte
+		for j, w := range d.secret {
+			secret = f.Add(secret, f.Mul(w, ys[j]))
+		}
+		return secret, nil
+	}
+
+	p, err := d.berlekampWelch(ys)
+	if nil != err {
+		return 0, err
+	}
+	corrupted := 0
+	for i, x := range d.xs {
+		if polyEval(f, p, x) != ys[i] {
+			bad[i] = true
+			corrupted++
+		}
+	}
+	if corrupted > d.errors {
+		return 0, ErrTooManyCorrupted
+	}
+	return p[0], nil
+}
+
+// berlekampWelch finds the error locator E of degree e and Q = P*E of degree
+// less than threshold+e satisfying Q(x) = y*E(x) for all shares and returns P.
+func (d *decoder) berlekampWelch(ys []byte) ([]byte, error) {
+	f := d.field
+	e := d.errors
+	if e == 0 {
+		return nil, ErrTooManyCorrupted
+	}
+
+	// The unknowns are the coefficients of Q followed by the coefficients
+	// of E except the leading one, which is fixed to 1.
+	qn := d.threshold + e
+	cols := qn + e
+	rows := make([][]byte, len(d.xs))
+	for i, x := range d.xs {
+		row := make([]byte, cols+1)
+		xp := byte(1)
+		for j := 0; j < qn; j++ {
+			row[j] = xp
+			if j < e {
+				row[qn+j] = f.Mul(ys[i], xp)
+			}
+			if j == e {
+				row[cols] = f.Mul(ys[i], xp)
+			}
+			xp = f.Mul(xp, x)
+		}
+		rows[i] = row
+	}
+
+	sol, ok := solve(f, rows, cols)
+	if !ok {
+		return nil, ErrTooManyCorrupted
+	}
+
+	q := sol[:qn]
+	errLocator := append(append([]byte(nil), sol[qn:]...), 1)
+	p, rem := polyDiv(f, q, errLocator)
+	for _, r := range rem {
+		if r != 0 {
+			return nil, ErrTooManyCorrupted
+		}
+	}
+	return p, nil
+}
+
+// solve solves the linear system given as augmented matrix over GF(2^8) using
+// Gaussian elimination. Free variables are set to zero. It reports false if
+// the system has no solution.
+func solve(f *Field, rows [][]byte, cols int) ([]byte, bool) {
+	pivots := make([]int, 0, cols)
+	r := 0
+	for c := 0; c < cols && r < len(rows); c++ {
+		p := -1
+		for i := r; i < len(rows); i++ {
+			if rows[i][c] != 0 {
+				p = i
+				break
+			}
+		}
+		if p < 0 {
+			continue
+		}
+		rows[r], rows[p] = rows[p], rows[r]
+
+		inv := f.Div(1, rows[r][c])
+		for j := c; j <= cols; j++ {
+			rows[r][j] = f.Mul(rows[r][j], inv)
+		}
+		for i := range rows {
+			if i != r && rows[i][c] != 0 {
+				factor := rows[i][c]
+				for j := c; j <= cols; j++ {
+					rows[i][j] = f.Add(rows[i][j], f.Mul(factor, rows[r][j]))
+				}
+			}
+		}
+		pivots = append(pivots, c)
+		r++
+	}
+
+	for i := r; i < len(rows); i++ {
+		if rows[i][cols] != 0 {
+			return nil, false
+		}
+	}
+
+	sol := make([]byte, cols)
+	for i, c := range pivots {
+		sol[c] = rows[i][cols]
+	}
+	return sol, true
+}
+
+// polyEval evaluates the polynomial with the given coefficients, lowest
+// degree first, at x.
+func polyEval(f *Field, coeffs []byte, x byte) byte {
+	var out byte
+	for i := len(coeffs) - 1; i >= 0; i-- {
+		out = f.Add(f.Mul(out, x), coeffs[i])
+	}
+	return out
+}
+
+// polyDiv divides num by the monic polynomial den, both given with the lowest
+// degree first, and returns quotient and remainder.
+func polyDiv(f *Field, num, den []byte) ([]byte, []byte) {
+	rem := append([]byte(nil), num...)
+	if len(num) < len(den) {
+		return nil, rem
+	}
+	quo := make([]byte, len(num)-len(den)+1)
+	for i := len(quo) - 1; i >= 0; i-- {
+		factor := rem[i+len(den)-1]
+		quo[i] = factor
+		for j, c := range den {
+			rem[i+j] = f.Add(rem[i+j], f.Mul(factor, c))
+		}
+	}
+	return quo, rem[:len(den)-1]
+}
+
+// corruptedXs returns the x coordinates marked in bad, in ascending order.
+func corruptedXs(xs []byte, bad []bool) []byte {
+	var out []byte
+	for i, x := range xs {
+		if bad[i] {
+			out = append(out, x)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// CombineRobust works like Combine but tolerates corrupted shares. Given more
+// than `threshold` parts, up to (len(parts)-threshold)/2 corrupted parts are
+// detected and corrected. The x coordinates of the corrupted parts are
+// returned along with the secret.
+//
+// ErrTooManyCorrupted is returned if the corruption cannot be corrected. With
+// exactly `threshold` parts nothing can be detected.
+func CombineRobust(parts map[byte][]byte, threshold int, opts ...Option) ([]byte, []byte, error) {
+	// Verify the parts are all the same length
+	var firstPartLen int
+	for x := range parts {
+		firstPartLen = len(parts[x])
+		break
+	}
+	for _, part := range parts {
+		if len(part) != firstPartLen {
+			return nil, nil, fmt.Errorf("all parts must be the same length")
+		}
+	}
+
+	xs := make([]byte, 0, len(parts))
+	values := make([][]byte, 0, len(parts))
+	for x, part := range parts {
+		xs = append(xs, x)
+		values = append(values, part)
+	}
+	d, err := newDecoder(newConfig(opts).field, xs, threshold)
+	if nil != err {
+		return nil, nil, err
+	}
+
+	secret := make([]byte, firstPartLen)
+	ys := make([]byte, len(xs))
+	bad := make([]bool, len(xs))
+	for i := range secret {
+		for j, v := range values {
+			ys[j] = v[i]
+		}
+		if secret[i], err = d.decode(ys, bad); nil != err {
+			return nil, nil, err
+		}
+	}
+
+	return secret, corruptedXs(xs, bad), nil
+}
+
+// RobustReader reconstructs a secret from parts of which some may be
+// corrupted. See CombineRobust.
+type RobustReader struct {
+	readers []io.Reader
+	xs      []byte
+	decoder *decoder
+	bufs    [][]byte
+	ys      []byte
+	bad     []bool
+	eof     bool
+}
+
+// NewRobustReader returns a reader reconstructing the secret from the given
+// parts, correcting up to (len(readers)-threshold)/2 corrupted parts.
+func NewRobustReader(readers map[byte]io.Reader, threshold int, opts ...Option) (*RobustReader, error) {
+	r := RobustReader{
+		readers: make([]io.Reader, 0, len(readers)),
+		xs:      make([]byte, 0, len(readers)),
+		bufs:    make([][]byte, len(readers)),
+		ys:      make([]byte, len(readers)),
+		bad:     make([]bool, len(readers)),
+	}
+	for x, ir := range readers {
+		r.xs = append(r.xs, x)
+		r.readers = append(r.readers, ir)
+	}
+
+	var err error
+	if r.decoder, err = newDecoder(newConfig(opts).field, r.xs, threshold); nil != err {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (r *RobustReader) Read(p []byte) (int, error) {
+	if r.eof {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if len(r.bufs[0]) < len(p) {
+		for j := range r.bufs {
+			r.bufs[j] = make([]byte, len(p))
+		}
+	}
+
+	// The first part decides how many bytes are read, all others must
+	// deliver the same amount.
+	n, err := io.ReadAtLeast(r.readers[0], r.bufs[0][:len(p)], 1)
+	if io.EOF == err {
+		r.eof = true
+		return 0, checkEOF(r.readers[1:])
+	} else if nil != err {
+		return 0, err
+	}
+	for j, ir := range r.readers[1:] {
+		if _, err := io.ReadFull(ir, r.bufs[j+1][:n]); io.EOF == err || io.ErrUnexpectedEOF == err {
+			return 0, fmt.Errorf("input must be of equal length")
+		} else if nil != err {
+			return 0, err
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		for j, buf := range r.bufs {
+			r.ys[j] = buf[i]
+		}
+		if p[i], err = r.decoder.decode(r.ys, r.bad); nil != err {
+			return 0, err
+		}
+	}
+
+	return n, nil
+}
+
+// Corrupted returns the x coordinates of the parts found to be corrupted in
+// the data read so far.
+func (r *RobustReader) Corrupted() []byte {
+	return corruptedXs(r.xs, r.bad)
+}
@@ -0,0 +1,132 @@
+package shamir
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+	"testing/iotest"
+)
+
+func TestPolyDiv(t *testing.T) {
+	// (x + 2)(x + 3) = x^2 + x + 6
+	quo, rem := polyDiv(DefaultField, []byte{6, 1, 1}, []byte{2, 1})
+	if !bytes.Equal(quo, []byte{3, 1}) || !bytes.Equal(rem, []byte{0}) {
+		t.Fatalf("bad: %v %v", quo, rem)
+	}
+
+	quo, rem = polyDiv(DefaultField, []byte{7, 1, 1}, []byte{2, 1})
+	if !bytes.Equal(quo, []byte{3, 1}) || !bytes.Equal(rem, []byte{1}) {
+		t.Fatalf("bad: %v %v", quo, rem)
+	}
+}
+
+func robustParts(t *testing.T, parts, threshold int) map[byte][]byte {
+	out, err := Split(secret, parts, threshold, WithRand(&deterministicReader{}))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return out
+}
+
+func TestCombineRobust(t *testing.T) {
+	parts := robustParts(t, 7, 3)
+
+	recomb, bad, err := CombineRobust(parts, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recomb, secret) || len(bad) != 0 {
+		t.Fatalf("bad: %v %v", recomb, bad)
+	}
+
+	// Up to (7-3)/2 = 2 corrupted parts can be corrected.
+	var corrupted []byte
+	for x, part := range parts {
+		if len(corrupted) == 2 {
+			break
+		}
+		part[0] ^= 0xff
+		part[len(part)-1] ^= 0x01
+		corrupted = append(corrupted, x)
+	}
+	if corrupted[0] > corrupted[1] {
+		corrupted[0], corrupted[1] = corrupted[1], corrupted[0]
+	}
+
+	recomb, bad, err = CombineRobust(parts, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recomb, secret) {
+		t.Fatalf("bad: %v %v", recomb, secret)
+	}
+	if !bytes.Equal(bad, corrupted) {
+		t.Fatalf("unexpected corrupted parts: got %v expected %v", bad, corrupted)
+	}
+}
+
+func TestCombineRobust_tooManyCorrupted(t *testing.T) {
+	parts := robustParts(t, 7, 3)
+	corrupted := 0
+	for _, part := range parts {
+		if corrupted == 3 {
+			break
+		}
+		corrupted++
+		part[5] ^= byte(corrupted)
+	}
+
+	if _, _, err := CombineRobust(parts, 3); err != ErrTooManyCorrupted {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCombineRobust_invalid(t *testing.T) {
+	parts := robustParts(t, 3, 2)
+
+	if _, _, err := CombineRobust(parts, 4); err != ErrNotEnoughShares {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, _, err := CombineRobust(parts, 1); err == nil {
+		t.Fatalf("should err")
+	}
+
+	for x := range parts {
+		parts[x] = parts[x][1:]
+		break
+	}
+	if _, _, err := CombineRobust(parts, 2); err == nil {
+		t.Fatalf("should err")
+	}
+}
+
+func TestRobustReader(t *testing.T) {
+	parts := robustParts(t, 5, 3)
+
+	var corrupted byte
+	for x, part := range parts {
+		part[3] ^= 0x10
+		corrupted = x
+		break
+	}
+
+	readers := make(map[byte]io.Reader, len(parts))
+	for x, part := range parts {
+		readers[x] = iotest.HalfReader(bytes.NewReader(part))
+	}
+	r, err := NewRobustReader(readers, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	result, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(result, secret) {
+		t.Fatalf("bad: %v %v", result, secret)
+	}
+	if bad := r.Corrupted(); !bytes.Equal(bad, []byte{corrupted}) {
+		t.Fatalf("unexpected corrupted parts: got %v expected %v", bad, []byte{corrupted})
+	}
+}
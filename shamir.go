@@ -2,41 +2,16 @@ package shamir
 
 import (
 	"bytes"
-	"crypto/rand"
-	"crypto/subtle"
 	"fmt"
 	"io"
 )
 
-// an x/y pair
-type pair struct {
-	x, y byte
-}
-
 // polynomial represents a polynomial of arbitrary degree
 type polynomial struct {
+	field        *Field
 	coefficients []uint8
 }
 
-// makePolynomial constructs a random polynomial of the given
-// degree but with the provided intercept value.
-func makePolynomial(intercept, degree uint8) (polynomial, error) {
-	// Create a wrapper
-	p := polynomial{
-		coefficients: make([]byte, degree+1),
-	}
-
-	// Ensure the intercept is set
-	p.coefficients[0] = intercept
-
-	// Assign random co-efficients to the polynomial
-	if _, err := rand.Read(p.coefficients[1:]); err != nil {
-		return p, err
-	}
-
-	return p, nil
-}
-
 // evaluate returns the value of the polynomial for the given x
 func (p *polynomial) evaluate(x byte) byte {
 	// Special case the origin
@@ -49,134 +24,151 @@ func (p *polynomial) evaluate(x byte) byte {
 	out := p.coefficients[degree]
 	for i := degree - 1; i >= 0; i-- {
 		coeff := p.coefficients[i]
-		out = add(mult(out, x), coeff)
+		out = p.field.Add(p.field.Mul(out, x), coeff)
 	}
 	return out
 }
 
-// Lagrange interpolation
-//
-// Takes N sample points and returns the value at a given x using a lagrange interpolation.
-func interpolate(points []pair, x byte) (value byte) {
-	for i, a := range points {
-		weight := byte(1)
-		for j, b := range points {
-			if i != j {
-				top := x ^ b.x
-				bottom := a.x ^ b.x
-				factor := div(top, bottom)
-				weight = mult(weight, factor)
-			}
+// blockSize limits the number of secret bytes processed at once by the
+// writer. Each part receives a single write per block.
+const blockSize = 32 * 1024
+
+type writer struct {
+	xs        []byte
+	writers   []io.Writer
+	blocks    [][]byte
+	coeffs    []byte
+	threshold int
+	rand      io.Reader
+	field     *Field
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		end := n + blockSize
+		if end > len(p) {
+			end = len(p)
 		}
-		value = value ^ mult(weight, a.y)
+		if err := w.writeBlock(p[n:end]); nil != err {
+			return n, err
+		}
+		n = end
 	}
-	return
+
+	return n, nil
 }
 
-// div divides two numbers in GF(2^8)
-func div(a, b uint8) uint8 {
-	if b == 0 {
-		// leaks some timing information but we don't care anyways as this
-		// should never happen, hence the panic
-		panic("divide by zero")
+// writeBlock computes the parts for a block of the secret and hands them to
+// the part writers with a single write call each.
+func (w *writer) writeBlock(p []byte) error {
+	if len(w.blocks[0]) < len(p) {
+		for j := range w.blocks {
+			w.blocks[j] = make([]byte, len(p))
+		}
 	}
 
-	var goodVal, zero uint8
-	log_a := logTable[a]
-	log_b := logTable[b]
-	diff := (int(log_a) - int(log_b)) % 255
-	if diff < 0 {
-		diff += 255
+	// Construct a random polynomial for each byte of the secret.
+	// Because we are using a field of size 256, we can only represent
+	// a single byte as the intercept of the polynomial, so we must
+	// use a new polynomial for each byte.
+	// The random coefficients for all polynomials of the block are read at
+	// once and wiped as soon as the block is done.
+	degree := w.threshold - 1
+	if len(w.coeffs) < len(p)*degree {
+		w.coeffs = make([]byte, len(p)*degree)
 	}
-
-	ret := expTable[diff]
-
-	// Ensure we return zero if a is zero but aren't subject to timing attacks
-	goodVal = ret
-
-	if subtle.ConstantTimeByteEq(a, 0) == 1 {
-		ret = zero
-	} else {
-		ret = goodVal
+	coeffs := w.coeffs[:len(p)*degree]
+	defer zero(coeffs)
+	if _, err := io.ReadFull(w.rand, coeffs); nil != err {
+		return fmt.Errorf("failed to generate polynomial: %v", err)
 	}
 
-	return ret
-}
-
-// mult multiplies two numbers in GF(2^8)
-func mult(a, b uint8) (out uint8) {
-	var goodVal, zero uint8
-	log_a := logTable[a]
-	log_b := logTable[b]
-	sum := (int(log_a) + int(log_b)) % 255
-
-	ret := expTable[sum]
-
-	// Ensure we return zero if either a or be are zero but aren't subject to
-	// timing attacks
-	goodVal = ret
+	poly := polynomial{field: w.field, coefficients: make([]byte, degree+1)}
+	defer zero(poly.coefficients)
+	for i, val := range p {
+		poly.coefficients[0] = val
+		copy(poly.coefficients[1:], coeffs[i*degree:(i+1)*degree])
 
-	if subtle.ConstantTimeByteEq(a, 0) == 1 {
-		ret = zero
-	} else {
-		ret = goodVal
+		// Generate a `parts` number of (x,y) pairs
+		for j, x := range w.xs {
+			w.blocks[j][i] = poly.evaluate(x)
+		}
 	}
 
-	if subtle.ConstantTimeByteEq(b, 0) == 1 {
-		ret = zero
-	} else {
-		// This operation does not do anything logically useful. It
-		// only ensures a constant number of assignments to thwart
-		// timing attacks.
-		goodVal = zero
+	for j, pw := range w.writers {
+		if _, err := pw.Write(w.blocks[j][:len(p)]); nil != err {
+			return fmt.Errorf("failed to write part: %v", err)
+		}
 	}
 
-	return ret
+	return nil
 }
 
-// add combines two numbers in GF(2^8)
-// This can also be used for subtraction since it is symmetric.
-func add(a, b uint8) uint8 {
-	return a ^ b
-}
+// randomCoordinates picks n distinct non-zero x coordinates.
+func randomCoordinates(r io.Reader, n int) ([]byte, error) {
+	var used [256]bool
+	xs := make([]byte, 0, n)
+	buf := make([]byte, 1)
+	for len(xs) < n {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		x := buf[0]
 
-type writer struct {
-	io.Writer
-	writers      map[byte]io.Writer
-	threshold    int
-	bytesWritten int
+		if x == 0 {
+			// We cannot use a zero x coordinate otherwise the y values would be the intercepts i.e. the secret value itself.
+			continue
+		}
+		if used[x] {
+			continue
+		}
+		used[x] = true
+		xs = append(xs, x)
+	}
+
+	return xs, nil
 }
 
-func (w *writer) Write(p []byte) (int, error) {
-	n := 0
-	// Construct a random polynomial for each byte of the secret.
-	// Because we are using a field of size 256, we can only represent
-	// a single byte as the intercept of the polynomial, so we must
-	// use a new polynomial for each byte.
-	for _, val := range p {
-		p, err := makePolynomial(val, uint8(w.threshold-1))
-		if nil != err {
-			return n, fmt.Errorf("failed to generate polynomial: %v", err)
+// addWriters validates the x coordinates and obtains a writer for each of them
+// from factory.
+func (w *writer) addWriters(xs []byte, factory func(x byte) (io.Writer, error)) error {
+	var used [256]bool
+	for _, x := range xs {
+		if x == 0 {
+			return fmt.Errorf("x coordinate cannot be zero")
 		}
+		if used[x] {
+			return fmt.Errorf("x coordinate %d is not unique", x)
+		}
+		used[x] = true
+	}
 
-		// Generate a `parts` number of (x,y) pairs
-		// We cheat by encoding the x value once as the final index,
-		// so that it only needs to be stored once.
-		for x, w := range w.writers {
-			y := p.evaluate(uint8(x))
-			_, err := w.Write([]byte{y})
-			if nil != err {
-				return n, fmt.Errorf("failed to write part: %v", err)
-			}
+	for _, x := range xs {
+		pw, err := factory(x)
+		if nil != err {
+			return err
 		}
-		n++
-		w.bytesWritten += n
+		w.xs = append(w.xs, x)
+		w.writers = append(w.writers, pw)
 	}
 
-	return n, nil
+	return nil
 }
 
-func NewWriter(parts, threshold int, factory func(x byte) (io.Writer, error)) (io.Writer, error) {
+// zero overwrites b with zeros.
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// NewWriter returns a writer splitting everything written to it into
+// `parts` number of shares, `threshold` of which are required to reconstruct
+// the secret. For each share, factory is called once with the x coordinate of
+// the share and must return the writer receiving the share. The input is
+// processed in blocks, resulting in a single write per share and block.
+func NewWriter(parts, threshold int, factory func(x byte) (io.Writer, error), opts ...Option) (io.Writer, error) {
 	// Sanity check the input
 	if parts < threshold {
 		return nil, fmt.Errorf("parts cannot be less than threshold")
@@ -188,28 +180,28 @@ func NewWriter(parts, threshold int, factory func(x byte) (io.Writer, error)) (i
 		return nil, fmt.Errorf("threshold must be at least 2")
 	}
 
-	result := writer{writers: make(map[byte]io.Writer, parts), threshold: threshold}
-
-	buf := make([]byte, 1)
-	for len(result.writers) < parts {
-		if _, err := rand.Read(buf); err != nil {
-			return nil, err
-		}
-		x := buf[0]
-
-		if x == 0 {
-			// We cannot use a zero x coordinate otherwise the y values would be the intercepts i.e. the secret value itself.
-			continue
-		}
-		if _, exists := result.writers[x]; exists {
-			continue
-		}
+	cfg := newConfig(opts)
+	result := writer{
+		xs:        make([]byte, 0, parts),
+		writers:   make([]io.Writer, 0, parts),
+		blocks:    make([][]byte, parts),
+		threshold: threshold,
+		rand:      cfg.rand,
+		field:     cfg.field,
+	}
 
-		w, err := factory(x)
-		if nil != err {
+	xs := cfg.xs
+	if nil == xs {
+		var err error
+		if xs, err = randomCoordinates(cfg.rand, parts); nil != err {
 			return nil, err
 		}
-		result.writers[x] = w
+	}
+	if len(xs) != parts {
+		return nil, fmt.Errorf("number of coordinates must match parts")
+	}
+	if err := result.addWriters(xs, factory); nil != err {
+		return nil, err
 	}
 
 	return &result, nil
@@ -220,13 +212,13 @@ func NewWriter(parts, threshold int, factory func(x byte) (io.Writer, error)) (i
 // the secret. The parts and threshold must be at least 2, and less
 // than 256. The returned shares are each one byte longer than the secret
 // as they attach a tag used to reconstruct the secret.
-func Split(secret []byte, parts, threshold int) (map[byte][]byte, error) {
+func Split(secret []byte, parts, threshold int, opts ...Option) (map[byte][]byte, error) {
 	buffers := make(map[byte]*bytes.Buffer, parts)
 	factory := func(x byte) (io.Writer, error) {
 		buffers[x] = &bytes.Buffer{}
 		return buffers[x], nil
 	}
-	s, err := NewWriter(parts, threshold, factory)
+	s, err := NewWriter(parts, threshold, factory, opts...)
 	if nil != err {
 		return nil, fmt.Errorf("failed to initilize writer: %v", err)
 	}
@@ -246,7 +238,18 @@ func Split(secret []byte, parts, threshold int) (map[byte][]byte, error) {
 
 // Combine is used to reverse a Split and reconstruct a secret
 // once a `threshold` number of parts are available.
-func Combine(parts map[byte][]byte) ([]byte, error) {
+//
+// The parts carry neither the threshold nor anything to identify the split
+// they belong to. Given fewer parts than the threshold, or parts of different
+// splits, Combine returns a wrong secret without an error. Use SplitShares and
+// CombineShares to have such mistakes detected.
+func Combine(parts map[byte][]byte, opts ...Option) ([]byte, error) {
+	return interpolateParts(parts, 0, newConfig(opts).field)
+}
+
+// interpolateParts returns the values at x of the polynomials passing through
+// the parts.
+func interpolateParts(parts map[byte][]byte, x byte, f *Field) ([]byte, error) {
 	// Verify enough parts provided
 	if len(parts) < 2 {
 		return nil, fmt.Errorf("less than two parts cannot be used to reconstruct the secret")
@@ -267,70 +270,122 @@ func Combine(parts map[byte][]byte) ([]byte, error) {
 		}
 	}
 
-	// Create a buffer to store the reconstructed secret
-	secret := make([]byte, firstPartLen)
-	points := make([]pair, len(parts))
+	// The x coordinates are the same for every byte of the secret, so the
+	// Lagrange weights only need to be computed once.
+	xs := make([]byte, 0, len(parts))
+	values := make([][]byte, 0, len(parts))
+	for x, part := range parts {
+		xs = append(xs, x)
+		values = append(values, part)
+	}
+	weights := f.lagrange(xs, x)
+
+	// Create a buffer to store the reconstructed values
+	out := make([]byte, firstPartLen)
 
-	for i := range secret {
-		p := 0
-		for k, v := range parts {
-			points[p] = pair{x: k, y: v[i]}
-			p++
+	for j, part := range values {
+		w := weights[j]
+		for i := range out {
+			out[i] = f.Add(out[i], f.Mul(w, part[i]))
 		}
-		secret[i] = interpolate(points, 0)
 	}
 
-	return secret, nil
+	return out, nil
 }
 
 type reader struct {
-	io.Reader
-	readers map[byte]io.Reader
+	field   *Field
+	readers []io.Reader
+	weights []byte
+	buf     []byte
 	eof     bool
 }
 
-func NewReader(readers map[byte]io.Reader) (io.Reader, error) {
+// NewReader returns a reader reconstructing the secret from the given parts.
+// The Lagrange weights for the x coordinates are computed once and reused for
+// every byte read.
+//
+// Like Combine, NewReader cannot detect too few parts or parts of different
+// splits and then returns a wrong secret. Use NewShareWriter and
+// NewShareReader to have such mistakes detected.
+func NewReader(readers map[byte]io.Reader, opts ...Option) (io.Reader, error) {
 	// Verify enough parts provided
 	if len(readers) < 2 {
 		return nil, fmt.Errorf("at least two parts are required to reconstruct the secret")
 	}
-	return &reader{readers: readers}, nil
+
+	return newReader(readers, 0, opts), nil
+}
+
+// newReader returns a reader interpolating the parts at x.
+func newReader(readers map[byte]io.Reader, x byte, opts []Option) *reader {
+	r := reader{
+		field:   newConfig(opts).field,
+		readers: make([]io.Reader, 0, len(readers)),
+	}
+	xs := make([]byte, 0, len(readers))
+	for x, ir := range readers {
+		xs = append(xs, x)
+		r.readers = append(r.readers, ir)
+	}
+	r.weights = r.field.lagrange(xs, x)
+
+	return &r
 }
 
 func (r *reader) Read(p []byte) (int, error) {
 	if r.eof {
 		return 0, io.EOF
 	}
-
-	points := make([][]pair, len(p))
-	for i := range points {
-		points[i] = make([]pair, len(r.readers))
+	if len(p) == 0 {
+		return 0, nil
 	}
 
-	j := 0
-	n := 0
+	if len(r.buf) < len(p) {
+		r.buf = make([]byte, len(p))
+	}
+	buf := r.buf[:len(p)]
+
+	// The first part decides how many bytes are read, all others must
+	// deliver the same amount.
+	n, err := io.ReadAtLeast(r.readers[0], buf, 1)
+	if io.EOF == err {
+		r.eof = true
+		return 0, checkEOF(r.readers[1:])
+	} else if nil != err {
+		return 0, err
+	}
+	for i := 0; i < n; i++ {
+		p[i] = r.field.Mul(r.weights[0], buf[i])
+	}
 
-	for x, ir := range r.readers {
-		buf := make([]byte, len(p))
-		m, err := ir.Read(buf)
-		if io.EOF == err {
-			r.eof = true
+	for j, ir := range r.readers[1:] {
+		if _, err := io.ReadFull(ir, buf[:n]); io.EOF == err || io.ErrUnexpectedEOF == err {
+			return 0, fmt.Errorf("input must be of equal length")
 		} else if nil != err {
 			return 0, err
-		} else if 0 != n && 0 != m && m != n {
-			return 0, fmt.Errorf("input must be of equal length")
 		}
-		n = m
-
-		for i := 0; i < m; i++ {
-			points[i][j] = pair{x: x, y: buf[i]}
+		w := r.weights[j+1]
+		for i := 0; i < n; i++ {
+			p[i] = r.field.Add(p[i], r.field.Mul(w, buf[i]))
 		}
-		j++
-	}
-
-	for m := 0; m < n; m++ {
-		p[m] = interpolate(points[m], 0)
 	}
 
 	return n, nil
 }
+
+// checkEOF ensures all remaining parts are exhausted once the first part
+// reached its end.
+func checkEOF(readers []io.Reader) error {
+	var b [1]byte
+	for _, ir := range readers {
+		n, err := io.ReadFull(ir, b[:])
+		if n > 0 {
+			return fmt.Errorf("input must be of equal length")
+		}
+		if io.EOF != err {
+			return err
+		}
+	}
+	return io.EOF
+}
@@ -2,7 +2,9 @@ package shamir
 
 import (
 	"bytes"
+	"crypto/rand"
 	"io"
+	"io/ioutil"
 	"testing"
 )
 
@@ -172,66 +174,66 @@ func TestCombine(t *testing.T) {
 }
 
 func TestField_Add(t *testing.T) {
-	if out := add(16, 16); out != 0 {
+	if out := DefaultField.Add(16, 16); out != 0 {
 		t.Fatalf("Bad: %v 16", out)
 	}
 
-	if out := add(3, 4); out != 7 {
+	if out := DefaultField.Add(3, 4); out != 7 {
 		t.Fatalf("Bad: %v 7", out)
 	}
 }
 
 func TestField_Mult(t *testing.T) {
-	if out := mult(3, 7); out != 9 {
+	if out := DefaultField.Mul(3, 7); out != 9 {
 		t.Fatalf("Bad: %v 9", out)
 	}
 
-	if out := mult(3, 0); out != 0 {
+	if out := DefaultField.Mul(3, 0); out != 0 {
 		t.Fatalf("Bad: %v 0", out)
 	}
 
-	if out := mult(0, 3); out != 0 {
+	if out := DefaultField.Mul(0, 3); out != 0 {
 		t.Fatalf("Bad: %v 0", out)
 	}
 }
 
 func TestField_Divide(t *testing.T) {
-	if out := div(0, 7); out != 0 {
+	if out := DefaultField.Div(0, 7); out != 0 {
 		t.Fatalf("Bad: %v 0", out)
 	}
 
-	if out := div(3, 3); out != 1 {
+	if out := DefaultField.Div(3, 3); out != 1 {
 		t.Fatalf("Bad: %v 1", out)
 	}
 
-	if out := div(6, 3); out != 2 {
+	if out := DefaultField.Div(6, 3); out != 2 {
 		t.Fatalf("Bad: %v 2", out)
 	}
 }
 
-func TestPolynomial_Random(t *testing.T) {
-	p, err := makePolynomial(42, 2)
-	if err != nil {
-		t.Fatalf("err: %v", err)
+// randomPolynomial returns a polynomial in the default field with random
+// coefficients but the given intercept.
+func randomPolynomial(t *testing.T, intercept, degree uint8) polynomial {
+	p := polynomial{
+		field:        DefaultField,
+		coefficients: make([]byte, degree+1),
 	}
-
-	if p.coefficients[0] != 42 {
-		t.Fatalf("bad: %v", p.coefficients)
+	if _, err := rand.Read(p.coefficients[1:]); err != nil {
+		t.Fatalf("err: %v", err)
 	}
+	p.coefficients[0] = intercept
+	return p
 }
 
 func TestPolynomial_Eval(t *testing.T) {
-	p, err := makePolynomial(42, 1)
-	if err != nil {
-		t.Fatalf("err: %v", err)
-	}
+	p := randomPolynomial(t, 42, 1)
 
 	if out := p.evaluate(0); out != 42 {
 		t.Fatalf("bad: %v", out)
 	}
 
 	out := p.evaluate(1)
-	exp := add(42, mult(1, p.coefficients[1]))
+	exp := DefaultField.Add(42, DefaultField.Mul(1, p.coefficients[1]))
 	if out != exp {
 		t.Fatalf("bad: %v %v %v", out, exp, p.coefficients)
 	}
@@ -239,20 +241,199 @@ func TestPolynomial_Eval(t *testing.T) {
 
 func TestInterpolate_Rand(t *testing.T) {
 	for i := 0; i < 256; i++ {
-		p, err := makePolynomial(uint8(i), 2)
+		p := randomPolynomial(t, uint8(i), 2)
+
+		xs := []byte{1, 2, 3}
+		ys := []byte{p.evaluate(1), p.evaluate(2), p.evaluate(3)}
+
+		out := DefaultField.Interpolate(xs, ys, 0)
+		if out != uint8(i) {
+			t.Fatalf("Bad: %v %d", out, i)
+		}
+	}
+}
+
+func TestLagrange(t *testing.T) {
+	p := randomPolynomial(t, 42, 2)
+
+	xs := []byte{1, 2, 3}
+	ys := []byte{p.evaluate(1), p.evaluate(2), p.evaluate(3)}
+
+	for _, x := range []byte{0, 1, 4, 255} {
+		var out byte
+		for i, w := range DefaultField.lagrange(xs, x) {
+			out = DefaultField.Add(out, DefaultField.Mul(w, ys[i]))
+		}
+		if exp := p.evaluate(x); out != exp {
+			t.Fatalf("bad: x %d got %v expected %v", x, out, exp)
+		}
+	}
+}
+
+func TestReader_unequalLength(t *testing.T) {
+	readers := map[byte]io.Reader{
+		1: bytes.NewReader([]byte("foo")),
+		2: bytes.NewReader([]byte("ba")),
+	}
+	r, err := NewReader(readers)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := ioutil.ReadAll(r); err == nil {
+		t.Fatalf("should err")
+	}
+}
+
+func benchmarkParts(b *testing.B, size, parts, threshold int) map[byte][]byte {
+	secret := make([]byte, size)
+	if _, err := rand.Read(secret); err != nil {
+		b.Fatalf("err: %v", err)
+	}
+	out, err := Split(secret, parts, threshold)
+	if err != nil {
+		b.Fatalf("err: %v", err)
+	}
+	for x := range out {
+		if len(out) == threshold {
+			break
+		}
+		delete(out, x)
+	}
+	return out
+}
+
+// BenchmarkInterpolate combines the parts byte by byte using
+// Field.Interpolate, recomputing the Lagrange weights for every byte.
+func BenchmarkInterpolate(b *testing.B) {
+	parts := benchmarkParts(b, 64*1024, 10, 5)
+	xs := make([]byte, 0, len(parts))
+	values := make([][]byte, 0, len(parts))
+	for x, part := range parts {
+		xs = append(xs, x)
+		values = append(values, part)
+	}
+	ys := make([]byte, len(parts))
+	b.SetBytes(64 * 1024)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for i := 0; i < 64*1024; i++ {
+			for j, part := range values {
+				ys[j] = part[i]
+			}
+			DefaultField.Interpolate(xs, ys, 0)
+		}
+	}
+}
+
+func BenchmarkCombine(b *testing.B) {
+	parts := benchmarkParts(b, 64*1024, 10, 5)
+	b.SetBytes(64 * 1024)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if _, err := Combine(parts); err != nil {
+			b.Fatalf("err: %v", err)
+		}
+	}
+}
+
+func BenchmarkReader(b *testing.B) {
+	parts := benchmarkParts(b, 64*1024, 10, 5)
+	b.SetBytes(64 * 1024)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		readers := make(map[byte]io.Reader, len(parts))
+		for x, part := range parts {
+			readers[x] = bytes.NewReader(part)
+		}
+		r, err := NewReader(readers)
 		if err != nil {
-			t.Fatalf("err: %v", err)
+			b.Fatalf("err: %v", err)
+		}
+		if _, err := io.Copy(ioutil.Discard, r); err != nil {
+			b.Fatalf("err: %v", err)
+		}
+	}
+}
+
+type countingWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(p)
+}
+
+func TestWriter_blocks(t *testing.T) {
+	writers := make(map[byte]*countingWriter, 5)
+	w, err := NewWriter(5, 3, func(x byte) (io.Writer, error) {
+		writers[x] = &countingWriter{}
+		return writers[x], nil
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	secret := make([]byte, 2*blockSize+10)
+	if n, err := w.Write(secret[:100]); err != nil || n != 100 {
+		t.Fatalf("bad: %d %v", n, err)
+	}
+	if n, err := w.Write(secret[100:]); err != nil || n != len(secret)-100 {
+		t.Fatalf("bad: %d %v", n, err)
+	}
+
+	parts := make(map[byte][]byte, len(writers))
+	for x, cw := range writers {
+		if cw.writes != 3 {
+			t.Fatalf("unexpected number of writes: got %d expected 3", cw.writes)
 		}
+		parts[x] = cw.Bytes()
+	}
 
-		pairs := []pair{
-			{x: 1, y: p.evaluate(1)},
-			{x: 2, y: p.evaluate(2)},
-			{x: 3, y: p.evaluate(3)},
+	recomb, err := Combine(parts)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recomb, secret) {
+		t.Fatalf("bad: %v", recomb)
+	}
+}
+
+func BenchmarkWriter(b *testing.B) {
+	secret := make([]byte, 64*1024)
+	b.SetBytes(64 * 1024)
+	for n := 0; n < b.N; n++ {
+		w, err := NewWriter(5, 3, func(x byte) (io.Writer, error) {
+			return ioutil.Discard, nil
+		})
+		if err != nil {
+			b.Fatalf("err: %v", err)
 		}
+		if _, err := w.Write(secret); err != nil {
+			b.Fatalf("err: %v", err)
+		}
+	}
+}
 
-		out := interpolate(pairs, 0)
-		if out != uint8(i) {
-			t.Fatalf("Bad: %v %d", out, i)
+func TestWriter_zeroCoefficients(t *testing.T) {
+	w, err := NewWriter(3, 3, func(x byte) (io.Writer, error) {
+		return ioutil.Discard, nil
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := w.Write([]byte("secret")); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	coeffs := w.(*writer).coeffs
+	if len(coeffs) != 12 {
+		t.Fatalf("unexpected number of coefficients: %d", len(coeffs))
+	}
+	for _, c := range coeffs {
+		if c != 0 {
+			t.Fatalf("coefficients not wiped: %v", coeffs)
 		}
 	}
 }
@@ -0,0 +1,611 @@
+package shamir
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrNotEnoughShares is returned when fewer shares than the threshold
+	// recorded with them are combined.
+	ErrNotEnoughShares = errors.New("not enough shares to reconstruct the secret")
+
+	// ErrVerification is returned when the reconstructed secret does not
+	// match the digest computed when the secret was split.
+	ErrVerification = errors.New("reconstruction failed verification")
+)
+
+// SetID identifies the shares created by a single split operation.
+type SetID [16]byte
+
+func (id SetID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// Share is a single share in a self-describing form. Besides the value of the
+// share, it carries everything needed to reconstruct and verify the secret
+// together with the other shares of the same set.
+//
+// The binary encoding of a share consists of the following fields:
+//
+//	magic      4 bytes  "GFSS"
+//	version    1 byte   format version, currently 2
+//	x          1 byte   x coordinate of the share
+//	threshold  1 byte   number of shares required to reconstruct the secret
+//	field      1 byte   reduction polynomial without the x^8 term, version 2 only
+//	set id    16 bytes  identifier of the split operation
+//	salt      16 bytes  key of the digest, version 2 only
+//	length     8 bytes  big endian length of the secret, 0 if unknown
+//	value      n bytes  the y values of the share
+//	tag       32 bytes  the y values of the digest, version 2 only
+//
+// Version 1 always uses DefaultField. A share without a tag is encoded using
+// version 1, any other share using version 2.
+//
+// The value of a share written by NewShareWriter extends to the end of the
+// stream, less the tag, as the length of the secret is not known upfront.
+type Share struct {
+	X         byte
+	Threshold int
+	SetID     SetID
+	Value     []byte
+
+	// Field is the field the share was computed in, nil means DefaultField.
+	Field *Field
+
+	// Tag holds the share of the HMAC-SHA256 of the secret keyed with Salt.
+	// The digest is split along with the secret, so it does not reveal
+	// anything about the secret unless enough shares are combined. Tag is nil
+	// for shares of format version 1 which can not be verified.
+	Tag []byte
+
+	// Salt keys the digest. It is the set identifier of the original split
+	// and is kept when the shares are reshared, while the set identifier
+	// changes.
+	Salt [16]byte
+}
+
+const (
+	shareMagic   = "GFSS"
+	shareVersion = 2
+	shareTagSize = sha256.Size
+)
+
+// header holds the fields preceding the value of an encoded share.
+type header struct {
+	version   byte
+	x         byte
+	threshold int
+	field     *Field
+	setID     SetID
+	salt      [16]byte
+	length    uint64
+}
+
+// headerSize returns the size of the header of the given format version.
+func headerSize(version byte) int {
+	if version == 1 {
+		return 4 + 1 + 1 + 1 + 16 + 8
+	}
+	return 4 + 1 + 1 + 1 + 1 + 16 + 16 + 8
+}
+
+func (h *header) marshal() []byte {
+	b := make([]byte, headerSize(h.version))
+	copy(b, shareMagic)
+	b[4] = h.version
+	b[5] = h.x
+	b[6] = byte(h.threshold)
+	rest := b[7:]
+	if h.version != 1 {
+		b[7] = byte(h.field.Poly())
+		rest = b[8:]
+	}
+	rest = rest[copy(rest, h.setID[:]):]
+	if h.version != 1 {
+		rest = rest[copy(rest, h.salt[:]):]
+	}
+	binary.BigEndian.PutUint64(rest, h.length)
+	return b
+}
+
+func (h *header) unmarshal(b []byte) error {
+	if len(b) < 5 {
+		return fmt.Errorf("share is too short")
+	}
+	if string(b[:4]) != shareMagic {
+		return fmt.Errorf("not a share")
+	}
+	if b[4] < 1 || b[4] > shareVersion {
+		return fmt.Errorf("unsupported share version %d", b[4])
+	}
+	if len(b) < headerSize(b[4]) {
+		return fmt.Errorf("share is too short")
+	}
+	h.version = b[4]
+	h.x = b[5]
+	h.threshold = int(b[6])
+	h.field = DefaultField
+	rest := b[7:]
+	if h.version != 1 {
+		f, err := NewField(0x100 | uint16(b[7]))
+		if nil != err {
+			return err
+		}
+		h.field = f
+		rest = b[8:]
+	}
+	rest = rest[copy(h.setID[:], rest):]
+	if h.version != 1 {
+		rest = rest[copy(h.salt[:], rest):]
+	}
+	h.length = binary.BigEndian.Uint64(rest)
+
+	if h.x == 0 {
+		return fmt.Errorf("x coordinate cannot be zero")
+	}
+	if h.threshold < 2 {
+		return fmt.Errorf("threshold must be at least 2")
+	}
+	return nil
+}
+
+// readHeader reads the header of a share from r.
+func readHeader(r io.Reader) (header, error) {
+	var h header
+	b := make([]byte, headerSize(shareVersion))
+	if _, err := io.ReadFull(r, b[:5]); nil != err {
+		return h, fmt.Errorf("failed to read share header: %v", err)
+	}
+	if b[4] >= 1 && b[4] <= shareVersion {
+		b = b[:headerSize(b[4])]
+		if _, err := io.ReadFull(r, b[5:]); nil != err {
+			return h, fmt.Errorf("failed to read share header: %v", err)
+		}
+	}
+	return h, h.unmarshal(b)
+}
+
+// tagSize returns the size of the tag following the value of the share.
+func (h *header) tagSize() int {
+	if h.version == 1 {
+		return 0
+	}
+	return shareTagSize
+}
+
+func (s *Share) header() header {
+	h := header{
+		version:   shareVersion,
+		x:         s.X,
+		threshold: s.Threshold,
+		field:     s.Field,
+		setID:     s.SetID,
+		salt:      s.Salt,
+		length:    uint64(len(s.Value)),
+	}
+	if nil == h.field {
+		h.field = DefaultField
+	}
+	if nil == s.Tag {
+		h.version = 1
+	}
+	return h
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (s *Share) MarshalBinary() ([]byte, error) {
+	if s.Threshold < 2 || s.Threshold > 255 {
+		return nil, fmt.Errorf("threshold must be between 2 and 255")
+	}
+	if nil != s.Tag && len(s.Tag) != shareTagSize {
+		return nil, fmt.Errorf("tag must be %d bytes long", shareTagSize)
+	}
+	h := s.header()
+	if h.version == 1 && h.field != DefaultField {
+		return nil, fmt.Errorf("share without tag must use the default field")
+	}
+	b := append(h.marshal(), s.Value...)
+	return append(b, s.Tag...), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (s *Share) UnmarshalBinary(data []byte) error {
+	var h header
+	if err := h.unmarshal(data); nil != err {
+		return err
+	}
+	value := data[headerSize(h.version):]
+	if len(value) < h.tagSize() {
+		return fmt.Errorf("share is too short")
+	}
+	value, tag := value[:len(value)-h.tagSize()], value[len(value)-h.tagSize():]
+	if h.length != 0 && h.length != uint64(len(value)) {
+		return fmt.Errorf("share length does not match its header")
+	}
+
+	s.X = h.x
+	s.Threshold = h.threshold
+	s.SetID = h.setID
+	s.Value = append([]byte(nil), value...)
+	s.Field = nil
+	if h.field != DefaultField {
+		s.Field = h.field
+	}
+	s.Tag = nil
+	if h.version != 1 {
+		s.Tag = append([]byte(nil), tag...)
+	}
+	s.Salt = h.salt
+	return nil
+}
+
+// SetMismatchError is returned when shares created by different split
+// operations are combined.
+type SetMismatchError struct {
+	// SetID is the set identifier shared by most of the shares.
+	SetID SetID
+	// X lists the x coordinates of the shares not belonging to SetID.
+	X []byte
+}
+
+func (e *SetMismatchError) Error() string {
+	xs := make([]string, len(e.X))
+	for i, x := range e.X {
+		xs[i] = strconv.Itoa(int(x))
+	}
+	return fmt.Sprintf("shares %s do not belong to share set %s", strings.Join(xs, ", "), e.SetID)
+}
+
+// checkHeaders ensures the shares described by the headers belong to the same
+// share set and are enough to reconstruct the secret.
+func checkHeaders(hs []header) error {
+	if len(hs) == 0 {
+		return ErrNotEnoughShares
+	}
+
+	var used [256]bool
+	for _, h := range hs {
+		if used[h.x] {
+			return fmt.Errorf("duplicate share for x coordinate %d", h.x)
+		}
+		used[h.x] = true
+	}
+
+	// The set identifier used by most of the shares is taken as the
+	// reference.
+	counts := make(map[SetID]int, 1)
+	best := hs[0].setID
+	for _, h := range hs {
+		counts[h.setID]++
+		if counts[h.setID] > counts[best] {
+			best = h.setID
+		}
+	}
+	if counts[best] != len(hs) {
+		err := &SetMismatchError{SetID: best}
+		for _, h := range hs {
+			if h.setID != best {
+				err.X = append(err.X, h.x)
+			}
+		}
+		sort.Slice(err.X, func(i, j int) bool { return err.X[i] < err.X[j] })
+		return err
+	}
+
+	for _, h := range hs[1:] {
+		if h.tagSize() != hs[0].tagSize() || h.threshold != hs[0].threshold || h.field.Poly() != hs[0].field.Poly() || h.salt != hs[0].salt {
+			return fmt.Errorf("shares of the same set must have the same format, threshold, field and salt")
+		}
+	}
+	if len(hs) < hs[0].threshold {
+		return ErrNotEnoughShares
+	}
+	return nil
+}
+
+// CheckShares ensures the shares belong to the same share set and are enough
+// to reconstruct the secret, like CombineShares and NewShareReader do before
+// combining them. Only the fields preceding the value are checked.
+//
+// It returns a *SetMismatchError if the shares do not belong to the same
+// share set and ErrNotEnoughShares if fewer shares than the recorded
+// threshold are given.
+func CheckShares(shares []*Share) error {
+	hs := make([]header, len(shares))
+	for i, s := range shares {
+		hs[i] = s.header()
+	}
+	return checkHeaders(hs)
+}
+
+// newSetID returns the share set identifier given using WithSetID or a random
+// one.
+func newSetID(cfg *config) (SetID, error) {
+	if nil != cfg.setID {
+		return *cfg.setID, nil
+	}
+	var id SetID
+	if _, err := io.ReadFull(cfg.rand, id[:]); nil != err {
+		return id, fmt.Errorf("failed to generate set id: %v", err)
+	}
+	return id, nil
+}
+
+// newDigest returns the hash used to compute the digest of a secret.
+func newDigest(salt [16]byte) hash.Hash {
+	return hmac.New(sha256.New, salt[:])
+}
+
+// SplitShares works like Split but returns the shares in their self-describing
+// form, ordered by x coordinate.
+//
+// A digest of the secret is split along with the secret, allowing
+// CombineShares to detect a failed reconstruction.
+func SplitShares(secret []byte, parts, threshold int, opts ...Option) ([]*Share, error) {
+	cfg := newConfig(opts)
+	id, err := newSetID(cfg)
+	if nil != err {
+		return nil, err
+	}
+
+	// The set identifier of the original split salts the digest.
+	mac := newDigest(id)
+	mac.Write(secret)
+	buf := mac.Sum(append(make([]byte, 0, len(secret)+shareTagSize), secret...))
+	defer zero(buf)
+
+	out, err := Split(buf, parts, threshold, opts...)
+	if nil != err {
+		return nil, err
+	}
+
+	shares := make([]*Share, 0, len(out))
+	for x, value := range out {
+		shares = append(shares, &Share{
+			X:         x,
+			Threshold: threshold,
+			SetID:     id,
+			Value:     value[:len(secret):len(secret)],
+			Tag:       value[len(secret):],
+			Salt:      id,
+		})
+		if cfg.field != DefaultField {
+			shares[len(shares)-1].Field = cfg.field
+		}
+	}
+	sort.Slice(shares, func(i, j int) bool { return shares[i].X < shares[j].X })
+
+	return shares, nil
+}
+
+// CombineShares is used to reverse a SplitShares and reconstruct a secret
+// once a `threshold` number of shares are available.
+//
+// It returns a *SetMismatchError if the shares do not belong to the same
+// share set, ErrNotEnoughShares if fewer shares than the recorded threshold
+// are given and ErrVerification if the reconstructed secret does not match
+// its digest.
+func CombineShares(shares []*Share) ([]byte, error) {
+	hs := make([]header, len(shares))
+	for i, s := range shares {
+		hs[i] = s.header()
+	}
+	if err := checkHeaders(hs); nil != err {
+		return nil, err
+	}
+
+	parts := make(map[byte][]byte, len(shares))
+	for _, s := range shares {
+		if nil != s.Tag && len(s.Tag) != shareTagSize {
+			return nil, fmt.Errorf("tag must be %d bytes long", shareTagSize)
+		}
+		parts[s.X] = append(append(make([]byte, 0, len(s.Value)+len(s.Tag)), s.Value...), s.Tag...)
+	}
+
+	buf, err := Combine(parts, WithField(hs[0].field))
+	if nil != err {
+		return nil, err
+	}
+	if nil == shares[0].Tag {
+		return buf, nil
+	}
+
+	secret, tag := buf[:len(buf)-shareTagSize], buf[len(buf)-shareTagSize:]
+	mac := newDigest(shares[0].Salt)
+	mac.Write(secret)
+	if !hmac.Equal(mac.Sum(nil), tag) {
+		zero(buf)
+		return nil, ErrVerification
+	}
+
+	return secret, nil
+}
+
+// shareWriter appends the shares of the digest of the secret on Close.
+type shareWriter struct {
+	io.Writer
+	mac    hash.Hash
+	closed bool
+}
+
+func (w *shareWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, fmt.Errorf("write to closed share writer")
+	}
+	n, err := w.Writer.Write(p)
+	w.mac.Write(p[:n])
+	return n, err
+}
+
+// Close writes the shares of the digest. It does not close the underlying
+// writers.
+func (w *shareWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	_, err := w.Writer.Write(w.mac.Sum(nil))
+	return err
+}
+
+// NewShareWriter works like NewWriter but writes the shares in their
+// self-describing form. The header of each share is written as soon as the
+// writer is obtained from factory.
+//
+// The shares are only complete once Close has been called, as this appends the
+// shares of the digest of the secret.
+func NewShareWriter(parts, threshold int, factory func(x byte) (io.Writer, error), opts ...Option) (io.WriteCloser, error) {
+	cfg := newConfig(opts)
+	id, err := newSetID(cfg)
+	if nil != err {
+		return nil, err
+	}
+
+	w, err := NewWriter(parts, threshold, func(x byte) (io.Writer, error) {
+		w, err := factory(x)
+		if nil != err {
+			return nil, err
+		}
+		h := header{version: shareVersion, x: x, threshold: threshold, field: cfg.field, setID: id, salt: id}
+		if _, err := w.Write(h.marshal()); nil != err {
+			return nil, fmt.Errorf("failed to write share header: %v", err)
+		}
+		return w, nil
+	}, opts...)
+	if nil != err {
+		return nil, err
+	}
+
+	return &shareWriter{Writer: w, mac: newDigest(id)}, nil
+}
+
+// NewShareReader works like NewReader but reads shares in their
+// self-describing form, as written by NewShareWriter or Share.MarshalBinary.
+//
+// The headers of all shares are read before NewShareReader returns. A
+// *SetMismatchError is returned if they do not belong to the same share set
+// and ErrNotEnoughShares if fewer shares than the recorded threshold are
+// given.
+//
+// The digest of the secret can only be checked once all of it has been read.
+// The last call to Read returns ErrVerification instead of io.EOF if the
+// verification failed; the data returned until then must not be trusted
+// before io.EOF has been seen.
+func NewShareReader(readers ...io.Reader) (io.Reader, error) {
+	parts := make(map[byte]io.Reader, len(readers))
+	hs := make([]header, 0, len(readers))
+	for _, r := range readers {
+		h, err := readHeader(r)
+		if nil != err {
+			return nil, err
+		}
+		if h.length != 0 {
+			r = &lengthReader{r: r, n: h.length + uint64(h.tagSize())}
+		}
+		parts[h.x] = r
+		hs = append(hs, h)
+	}
+	if err := checkHeaders(hs); nil != err {
+		return nil, err
+	}
+
+	r, err := NewReader(parts, WithField(hs[0].field))
+	if nil != err {
+		return nil, err
+	}
+	if hs[0].version == 1 {
+		return r, nil
+	}
+
+	return &verifyingReader{r: r, mac: newDigest(hs[0].salt)}, nil
+}
+
+// lengthReader reads exactly n bytes from r and fails if r ends early.
+type lengthReader struct {
+	r io.Reader
+	n uint64
+}
+
+func (l *lengthReader) Read(p []byte) (int, error) {
+	if l.n == 0 {
+		return 0, io.EOF
+	}
+	if uint64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= uint64(n)
+	if io.EOF == err && l.n > 0 {
+		return n, io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// verifyingReader holds back the digest at the end of the reconstructed
+// stream and compares it against the digest of the data read.
+type verifyingReader struct {
+	r    io.Reader
+	mac  hash.Hash
+	tail []byte
+	buf  []byte
+	err  error
+}
+
+func (v *verifyingReader) Read(p []byte) (int, error) {
+	if nil != v.err {
+		return 0, v.err
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	for {
+		if len(v.buf) < len(p)+shareTagSize {
+			v.buf = make([]byte, len(p)+shareTagSize)
+		}
+		buf := append(v.buf[:0], v.tail...)
+		n, err := v.r.Read(buf[len(buf):cap(buf)][:len(p)])
+		buf = buf[:len(buf)+n]
+
+		// Everything but the last bytes which might be the digest can
+		// be handed out.
+		m := len(buf) - shareTagSize
+		if m < 0 {
+			m = 0
+		}
+		copy(p, buf[:m])
+		v.mac.Write(buf[:m])
+		v.tail = append(v.tail[:0], buf[m:]...)
+
+		if io.EOF == err {
+			v.err = v.verify()
+			return m, nil
+		} else if nil != err {
+			v.err = err
+			return m, err
+		}
+		if m > 0 {
+			return m, nil
+		}
+	}
+}
+
+func (v *verifyingReader) verify() error {
+	if len(v.tail) != shareTagSize {
+		return fmt.Errorf("share is too short")
+	}
+	if !hmac.Equal(v.mac.Sum(nil), v.tail) {
+		return ErrVerification
+	}
+	return io.EOF
+}
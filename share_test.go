@@ -0,0 +1,453 @@
+package shamir
+
+import (
+	"bytes"
+	"encoding"
+	"io"
+	"io/ioutil"
+	"testing"
+	"testing/iotest"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = &Share{}
+	_ encoding.BinaryUnmarshaler = &Share{}
+)
+
+func TestShare_MarshalBinary(t *testing.T) {
+	s := &Share{
+		X:         42,
+		Threshold: 3,
+		SetID:     SetID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		Value:     []byte("foo"),
+	}
+
+	data, err := s.MarshalBinary()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	exp := []byte{
+		'G', 'F', 'S', 'S', 1, 42, 3,
+		1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16,
+		0, 0, 0, 0, 0, 0, 0, 3,
+		'f', 'o', 'o',
+	}
+	if !bytes.Equal(data, exp) {
+		t.Fatalf("bad: %v", data)
+	}
+
+	var out Share
+	if err := out.UnmarshalBinary(data); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.X != s.X || out.Threshold != s.Threshold || out.SetID != s.SetID || !bytes.Equal(out.Value, s.Value) {
+		t.Fatalf("bad: %v", out)
+	}
+}
+
+func TestShare_UnmarshalBinary_invalid(t *testing.T) {
+	valid := (&Share{X: 1, Threshold: 2, Value: []byte("foo")})
+	data, err := valid.MarshalBinary()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	tests := map[string]func([]byte) []byte{
+		"short":     func(b []byte) []byte { return b[:10] },
+		"magic":     func(b []byte) []byte { b[0] = 'X'; return b },
+		"version":   func(b []byte) []byte { b[4] = 99; return b },
+		"zero x":    func(b []byte) []byte { b[5] = 0; return b },
+		"threshold": func(b []byte) []byte { b[6] = 1; return b },
+		"length":    func(b []byte) []byte { return b[:len(b)-1] },
+	}
+	for name, mutate := range tests {
+		b := mutate(append([]byte(nil), data...))
+		var s Share
+		if err := s.UnmarshalBinary(b); err == nil {
+			t.Errorf("%s: expect error", name)
+		}
+	}
+}
+
+func TestSplitShares(t *testing.T) {
+	secret := []byte("test")
+
+	shares, err := SplitShares(secret, 5, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(shares) != 5 {
+		t.Fatalf("bad: %v", shares)
+	}
+	for i, s := range shares {
+		if s.Threshold != 3 || s.SetID != shares[0].SetID || len(s.Value) != len(secret) || len(s.Tag) != shareTagSize {
+			t.Fatalf("bad: %v", s)
+		}
+		if i > 0 && s.X <= shares[i-1].X {
+			t.Fatalf("shares not ordered: %v", shares)
+		}
+	}
+
+	recomb, err := CombineShares(shares[1:4])
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recomb, secret) {
+		t.Fatalf("bad: %v %v", recomb, secret)
+	}
+
+	if _, err := CombineShares([]*Share{shares[0], shares[0]}); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestShareWriter(t *testing.T) {
+	buffers := make(map[byte]*bytes.Buffer, 3)
+	w, err := NewShareWriter(3, 2, func(x byte) (io.Writer, error) {
+		buffers[x] = &bytes.Buffer{}
+		return buffers[x], nil
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := w.Write(secret); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	readers := make([]io.Reader, 0, len(buffers))
+	for x, buf := range buffers {
+		var s Share
+		if err := s.UnmarshalBinary(buf.Bytes()); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if s.X != x || s.Threshold != 2 || len(s.Value) != len(secret) || len(s.Tag) != shareTagSize {
+			t.Fatalf("bad: %v", s)
+		}
+		readers = append(readers, bytes.NewReader(buf.Bytes()))
+	}
+
+	r, err := NewShareReader(readers...)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	result, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(result, secret) {
+		t.Fatalf("bad: %v %v", result, secret)
+	}
+}
+
+func TestShareReader_length(t *testing.T) {
+	shares, err := SplitShares([]byte("test"), 2, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	a, _ := shares[0].MarshalBinary()
+	b, _ := shares[1].MarshalBinary()
+	r, err := NewShareReader(bytes.NewReader(a), bytes.NewReader(b[:len(b)-1]))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := ioutil.ReadAll(r); err == nil {
+		t.Fatalf("expect error")
+	}
+
+	if _, err := NewShareReader(bytes.NewReader(a), bytes.NewReader(a)); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestCombineShares_setMismatch(t *testing.T) {
+	a, err := SplitShares([]byte("test"), 3, 2, WithCoordinates(1, 2, 3))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	b, err := SplitShares([]byte("test"), 3, 2, WithCoordinates(1, 2, 3))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	shares := []*Share{a[0], b[1], a[2]}
+	_, err = CombineShares(shares)
+	mismatch, ok := err.(*SetMismatchError)
+	if !ok {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mismatch.SetID != a[0].SetID || !bytes.Equal(mismatch.X, []byte{b[1].X}) {
+		t.Fatalf("bad: %v", mismatch)
+	}
+
+	readers := make([]io.Reader, len(shares))
+	for i, s := range shares {
+		data, _ := s.MarshalBinary()
+		readers[i] = bytes.NewReader(data)
+	}
+	if _, err := NewShareReader(readers...); err == nil {
+		t.Fatalf("expect error")
+	} else if _, ok := err.(*SetMismatchError); !ok {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCheckShares(t *testing.T) {
+	a, err := SplitShares([]byte("test"), 3, 2, WithCoordinates(1, 2, 3))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	b, err := SplitShares([]byte("test"), 3, 2, WithCoordinates(1, 2, 3))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if err := CheckShares(a[1:]); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := CheckShares(a[:1]); err != ErrNotEnoughShares {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := CheckShares([]*Share{a[0], b[1], a[2]}).(*SetMismatchError); !ok {
+		t.Fatalf("expect error")
+	}
+	if err := CheckShares([]*Share{a[0], a[0]}); err == nil {
+		t.Fatalf("expect error")
+	}
+
+	// Only the fields preceding the value are checked.
+	if err := CheckShares([]*Share{{X: 1, Threshold: 2}, {X: 2, Threshold: 2}}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := CheckShares([]*Share{{X: 1, Threshold: 2}, {X: 2, Threshold: 2, Field: AESField}}); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestSetMismatchError(t *testing.T) {
+	err := &SetMismatchError{SetID: SetID{0xab}, X: []byte{3, 7}}
+	exp := "shares 3, 7 do not belong to share set ab000000000000000000000000000000"
+	if err.Error() != exp {
+		t.Fatalf("bad: %s", err)
+	}
+}
+
+func TestShare_MarshalBinary_tag(t *testing.T) {
+	s := &Share{
+		X:         42,
+		Threshold: 3,
+		SetID:     SetID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		Value:     []byte("foo"),
+		Tag:       bytes.Repeat([]byte{7}, shareTagSize),
+		Salt:      [16]byte{17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32},
+	}
+
+	data, err := s.MarshalBinary()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	exp := []byte{
+		'G', 'F', 'S', 'S', 2, 42, 3, 0x1d,
+		1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16,
+		17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32,
+		0, 0, 0, 0, 0, 0, 0, 3,
+		'f', 'o', 'o',
+	}
+	if !bytes.Equal(data, append(exp, s.Tag...)) {
+		t.Fatalf("bad: %v", data)
+	}
+
+	var out Share
+	if err := out.UnmarshalBinary(data); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.SetID != s.SetID || out.Salt != s.Salt || !bytes.Equal(out.Value, s.Value) || !bytes.Equal(out.Tag, s.Tag) {
+		t.Fatalf("bad: %v", out)
+	}
+
+	s.Tag = s.Tag[1:]
+	if _, err := s.MarshalBinary(); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestCombineShares_notEnoughShares(t *testing.T) {
+	shares, err := SplitShares([]byte("test"), 5, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if _, err := CombineShares(shares[:2]); err != ErrNotEnoughShares {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := CombineShares(nil); err != ErrNotEnoughShares {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Lying about the threshold does not help either.
+	for _, s := range shares {
+		s.Threshold = 2
+	}
+	if _, err := CombineShares(shares[:2]); err != ErrVerification {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCombineShares_verification(t *testing.T) {
+	shares, err := SplitShares([]byte("test"), 3, 2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	shares[1].Value[0] ^= 1
+	if _, err := CombineShares(shares); err != ErrVerification {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestShareReader_verification(t *testing.T) {
+	shares, err := SplitShares(secret, 5, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	readers := func(shares []*Share) []io.Reader {
+		readers := make([]io.Reader, len(shares))
+		for i, s := range shares {
+			data, _ := s.MarshalBinary()
+			readers[i] = bytes.NewReader(data)
+		}
+		return readers
+	}
+
+	if _, err := NewShareReader(readers(shares[:2])...); err != ErrNotEnoughShares {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r, err := NewShareReader(readers(shares[1:4])...)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	result, err := ioutil.ReadAll(iotest.OneByteReader(r))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(result, secret) {
+		t.Fatalf("bad: %v %v", result, secret)
+	}
+
+	shares[2].Tag[0] ^= 1
+	r, err = NewShareReader(readers(shares[1:4])...)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := ioutil.ReadAll(r); err != ErrVerification {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSplitShares_field(t *testing.T) {
+	secret := []byte("test")
+
+	shares, err := SplitShares(secret, 3, 2, WithField(AESField))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	decoded := make([]*Share, 0, len(shares))
+	for _, s := range shares {
+		data, err := s.MarshalBinary()
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if data[7] != 0x1b {
+			t.Fatalf("bad field: %x", data[7])
+		}
+		var out Share
+		if err := out.UnmarshalBinary(data); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if out.Field.Poly() != 0x11b {
+			t.Fatalf("bad: %v", out.Field)
+		}
+		decoded = append(decoded, &out)
+	}
+
+	recomb, err := CombineShares(decoded[:2])
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recomb, secret) {
+		t.Fatalf("bad: %v %v", recomb, secret)
+	}
+
+	mixed := *decoded[1]
+	mixed.Field = nil
+	if _, err := CombineShares([]*Share{decoded[0], &mixed}); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestShare_UnmarshalBinary_version(t *testing.T) {
+	s := &Share{X: 42, Threshold: 3, Value: []byte("foo")}
+	data, err := s.MarshalBinary()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Version 1 lacks the field byte and the tag.
+	if data[4] != 1 || len(data) != headerSize(1)+3 {
+		t.Fatalf("bad: %v", data)
+	}
+	var out Share
+	if err := out.UnmarshalBinary(data); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Field != nil || out.Tag != nil || out.X != 42 || !bytes.Equal(out.Value, s.Value) {
+		t.Fatalf("bad: %v", out)
+	}
+
+	data[4] = shareVersion + 1
+	if err := out.UnmarshalBinary(data); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestShareReader_field(t *testing.T) {
+	buffers := make(map[byte]*bytes.Buffer, 3)
+	w, err := NewShareWriter(3, 2, func(x byte) (io.Writer, error) {
+		buffers[x] = &bytes.Buffer{}
+		return buffers[x], nil
+	}, WithField(AESField))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := w.Write([]byte("test")); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	readers := make([]io.Reader, 0, 2)
+	for _, buf := range buffers {
+		readers = append(readers, buf)
+		if len(readers) == 2 {
+			break
+		}
+	}
+	r, err := NewShareReader(readers...)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if string(out) != "test" {
+		t.Fatalf("bad: %q", out)
+	}
+}
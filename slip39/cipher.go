@@ -0,0 +1,97 @@
+package slip39
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+const (
+	// baseIterationCount is the total number of PBKDF2 iterations of all
+	// rounds of the Feistel network for an iteration exponent of 0.
+	baseIterationCount = 10000
+	// roundCount is the number of rounds of the Feistel network.
+	roundCount = 4
+)
+
+// encrypt encrypts the master secret with the passphrase using the four round
+// Feistel network of SLIP-0039.
+func encrypt(ms, passphrase []byte, exp int, id uint16, extendable bool) []byte {
+	l, r := halves(ms)
+	s := salt(id, extendable)
+	for i := 0; i < roundCount; i++ {
+		l, r = r, xor(l, roundFunction(byte(i), passphrase, exp, s, r))
+	}
+	return append(r, l...)
+}
+
+// decrypt reverses encrypt.
+func decrypt(ems, passphrase []byte, exp int, id uint16, extendable bool) []byte {
+	l, r := halves(ems)
+	s := salt(id, extendable)
+	for i := roundCount - 1; i >= 0; i-- {
+		l, r = r, xor(l, roundFunction(byte(i), passphrase, exp, s, r))
+	}
+	return append(r, l...)
+}
+
+// halves returns copies of both halves of b.
+func halves(b []byte) ([]byte, []byte) {
+	l := append([]byte(nil), b[:len(b)/2]...)
+	r := append([]byte(nil), b[len(b)/2:]...)
+	return l, r
+}
+
+func xor(a, b []byte) []byte {
+	out := make([]byte, len(a))
+	for i := range a {
+		out[i] = a[i] ^ b[i]
+	}
+	return out
+}
+
+// salt returns the salt of the round function. Extendable backup shares do
+// not depend on the identifier, so new share sets can be created for the same
+// encrypted master secret.
+func salt(id uint16, extendable bool) []byte {
+	if extendable {
+		return nil
+	}
+	return append([]byte(customization), byte(id>>8), byte(id))
+}
+
+func roundFunction(i byte, passphrase []byte, exp int, salt, r []byte) []byte {
+	password := append([]byte{i}, passphrase...)
+	return pbkdf2(password, append(salt[:len(salt):len(salt)], r...), (baseIterationCount<<exp)/roundCount, len(r))
+}
+
+// pbkdf2 derives a key of keyLen bytes using PBKDF2 with HMAC-SHA256 as
+// defined by RFC 8018.
+func pbkdf2(password, salt []byte, iter, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	size := prf.Size()
+	blocks := (keyLen + size - 1) / size
+
+	var buf [4]byte
+	dk := make([]byte, 0, blocks*size)
+	u := make([]byte, size)
+	for block := 1; block <= blocks; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(buf[:], uint32(block))
+		prf.Write(buf[:])
+		dk = prf.Sum(dk)
+		t := dk[len(dk)-size:]
+		copy(u, t)
+
+		for n := 2; n <= iter; n++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for i := range u {
+				t[i] ^= u[i]
+			}
+		}
+	}
+	return dk[:keyLen]
+}
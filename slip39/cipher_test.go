@@ -0,0 +1,49 @@
+package slip39
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestPbkdf2(t *testing.T) {
+	// Test vectors of RFC 7914, section 11.
+	tests := []struct {
+		password, salt string
+		iter, keyLen   int
+		exp            string
+	}{
+		{"passwd", "salt", 1, 64, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+		{"Password", "NaCl", 80000, 64, "4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b34ab56a1d425a1225833549adb841b51c9b3176a272bdebba1d078478f62b397f33c8d"},
+	}
+	for _, test := range tests {
+		out := pbkdf2([]byte(test.password), []byte(test.salt), test.iter, test.keyLen)
+		if hex.EncodeToString(out) != test.exp {
+			t.Errorf("bad: %x", out)
+		}
+	}
+}
+
+func TestEncrypt(t *testing.T) {
+	ms := []byte("ABCDEFGHIJKLMNOP")
+	for _, extendable := range []bool{false, true} {
+		ems := encrypt(ms, []byte("TREZOR"), 0, 42, extendable)
+		if bytes.Equal(ems, ms) {
+			t.Fatalf("not encrypted")
+		}
+		if out := decrypt(ems, []byte("TREZOR"), 0, 42, extendable); !bytes.Equal(out, ms) {
+			t.Fatalf("bad: %q", out)
+		}
+		if out := decrypt(ems, nil, 0, 42, extendable); bytes.Equal(out, ms) {
+			t.Fatalf("expect different result")
+		}
+	}
+
+	// Only shares which are not extendable depend on the identifier.
+	if bytes.Equal(encrypt(ms, nil, 0, 1, false), encrypt(ms, nil, 0, 2, false)) {
+		t.Fatalf("expect different result")
+	}
+	if !bytes.Equal(encrypt(ms, nil, 0, 1, true), encrypt(ms, nil, 0, 2, true)) {
+		t.Fatalf("expect same result")
+	}
+}
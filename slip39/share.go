@@ -0,0 +1,230 @@
+package slip39
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidChecksum is returned if the checksum of a mnemonic does not
+// match, which indicates a mistyped word.
+var ErrInvalidChecksum = errors.New("invalid mnemonic checksum")
+
+const (
+	// customization is mixed into the checksum and the salt of the
+	// encryption of shares which are not extendable.
+	customization = "shamir"
+	// customizationExtendable is mixed into the checksum of extendable
+	// shares.
+	customizationExtendable = "shamir_extendable"
+
+	radixBits          = 10
+	idExpWords         = 2
+	groupWords         = 2
+	checksumWords      = 3
+	metadataWords      = idExpWords + groupWords + checksumWords
+	minStrengthBits    = 128
+	minMnemonicWords   = metadataWords + (minStrengthBits+radixBits-1)/radixBits
+	maxShareCount      = 16
+	maxIterationExp    = 15
+	maxIdentifierValue = 1<<15 - 1
+)
+
+// wordIndex maps the first four letters of every word to its index.
+var wordIndex = func() map[string]int {
+	m := make(map[string]int, len(wordlist))
+	for i, w := range wordlist {
+		m[w[:4]] = i
+	}
+	return m
+}()
+
+// Share is a single SLIP-0039 share.
+type Share struct {
+	// Identifier is the random 15 bit identifier shared by all shares of a
+	// master secret.
+	Identifier uint16
+	// Extendable indicates whether further share sets can be created for
+	// the same encrypted master secret.
+	Extendable bool
+	// IterationExponent sets the number of PBKDF2 iterations used to
+	// encrypt the master secret to 10000·2^IterationExponent.
+	IterationExponent int
+
+	GroupIndex      int
+	GroupThreshold  int
+	GroupCount      int
+	MemberIndex     int
+	MemberThreshold int
+
+	// Value is the share value, of the same length as the master secret.
+	Value []byte
+}
+
+// ParseShare parses a mnemonic. Words are matched case insensitive by their
+// first four letters, which must be followed by the remaining letters of the
+// word, if any.
+func ParseShare(mnemonic string) (*Share, error) {
+	words := strings.Fields(strings.ToLower(mnemonic))
+	if len(words) < minMnemonicWords {
+		return nil, fmt.Errorf("mnemonic must be at least %d words long", minMnemonicWords)
+	}
+
+	data := make([]int, len(words))
+	for i, w := range words {
+		if len(w) < 4 {
+			return nil, fmt.Errorf("unknown word %q", w)
+		}
+		idx, ok := wordIndex[w[:4]]
+		if !ok || !strings.HasPrefix(wordlist[idx], w) {
+			return nil, fmt.Errorf("unknown word %q", w)
+		}
+		data[i] = idx
+	}
+
+	var s Share
+	idExp := data[0]<<radixBits | data[1]
+	s.Identifier = uint16(idExp >> 5)
+	s.Extendable = idExp>>4&1 == 1
+	s.IterationExponent = idExp & 0xf
+
+	if 1 != rs1024Polymod(s.customization(), data) {
+		return nil, ErrInvalidChecksum
+	}
+
+	group := data[2]<<radixBits | data[3]
+	s.GroupIndex = group >> 16
+	s.GroupThreshold = group>>12&0xf + 1
+	s.GroupCount = group>>8&0xf + 1
+	s.MemberIndex = group >> 4 & 0xf
+	s.MemberThreshold = group&0xf + 1
+	if s.GroupThreshold > s.GroupCount {
+		return nil, fmt.Errorf("group threshold cannot exceed group count")
+	}
+
+	valueWords := data[idExpWords+groupWords : len(data)-checksumWords]
+	padding := radixBits * len(valueWords) % 16
+	if padding > 8 {
+		return nil, fmt.Errorf("invalid mnemonic length")
+	}
+	value, err := fromWords(valueWords, padding)
+	if nil != err {
+		return nil, err
+	}
+	s.Value = value
+
+	return &s, nil
+}
+
+// Mnemonic returns the share encoded as mnemonic.
+func (s *Share) Mnemonic() (string, error) {
+	if s.Identifier > maxIdentifierValue {
+		return "", fmt.Errorf("identifier must fit into 15 bits")
+	}
+	if s.IterationExponent < 0 || s.IterationExponent > maxIterationExp {
+		return "", fmt.Errorf("iteration exponent must be between 0 and %d", maxIterationExp)
+	}
+	if s.GroupThreshold < 1 || s.GroupThreshold > s.GroupCount || s.GroupCount > maxShareCount {
+		return "", fmt.Errorf("group threshold must be between 1 and the group count of at most %d", maxShareCount)
+	}
+	if s.GroupIndex < 0 || s.GroupIndex >= s.GroupCount {
+		return "", fmt.Errorf("group index must be less than the group count")
+	}
+	if s.MemberThreshold < 1 || s.MemberThreshold > maxShareCount {
+		return "", fmt.Errorf("member threshold must be between 1 and %d", maxShareCount)
+	}
+	if s.MemberIndex < 0 || s.MemberIndex >= maxShareCount {
+		return "", fmt.Errorf("member index must be less than %d", maxShareCount)
+	}
+	if 8*len(s.Value) < minStrengthBits || len(s.Value)%2 != 0 {
+		return "", fmt.Errorf("share value must be an even number of at least %d bytes", minStrengthBits/8)
+	}
+
+	idExp := int(s.Identifier)<<5 | s.IterationExponent
+	if s.Extendable {
+		idExp |= 1 << 4
+	}
+	group := s.GroupIndex<<16 | (s.GroupThreshold-1)<<12 | (s.GroupCount-1)<<8 | s.MemberIndex<<4 | (s.MemberThreshold - 1)
+
+	data := []int{idExp >> radixBits, idExp & 0x3ff, group >> radixBits, group & 0x3ff}
+	data = append(data, toWords(s.Value)...)
+	chk := rs1024Polymod(s.customization(), append(data, 0, 0, 0)) ^ 1
+	data = append(data, chk>>20&0x3ff, chk>>10&0x3ff, chk&0x3ff)
+
+	words := make([]string, len(data))
+	for i, idx := range data {
+		words[i] = wordlist[idx]
+	}
+	return strings.Join(words, " "), nil
+}
+
+func (s *Share) customization() string {
+	if s.Extendable {
+		return customizationExtendable
+	}
+	return customization
+}
+
+// toWords converts b into 10 bit words, padding it with leading zero bits.
+func toWords(b []byte) []int {
+	words := make([]int, (8*len(b)+radixBits-1)/radixBits)
+	acc, bits := 0, radixBits*len(words)-8*len(b)
+	i := 0
+	for _, v := range b {
+		acc = acc<<8 | int(v)
+		bits += 8
+		if bits >= radixBits {
+			bits -= radixBits
+			words[i] = acc >> bits & 0x3ff
+			acc &= 1<<bits - 1
+			i++
+		}
+	}
+	return words
+}
+
+// fromWords reverses toWords, checking the padding bits to be zero.
+func fromWords(words []int, padding int) ([]byte, error) {
+	if words[0]>>(radixBits-padding) != 0 {
+		return nil, fmt.Errorf("invalid mnemonic padding")
+	}
+	out := make([]byte, 0, (radixBits*len(words)-padding)/8)
+	acc, bits := words[0], radixBits-padding
+	acc &= 1<<bits - 1
+	for _, w := range words[1:] {
+		acc = acc<<radixBits | w
+		bits += radixBits
+		for bits >= 8 {
+			bits -= 8
+			out = append(out, byte(acc>>bits))
+		}
+		acc &= 1<<bits - 1
+	}
+	return out, nil
+}
+
+// rs1024Polymod computes the Reed-Solomon checksum of SLIP-0039 over the
+// customization string and the words.
+func rs1024Polymod(customization string, data []int) int {
+	gen := [10]int{
+		0xe0e040, 0x1c1c080, 0x3838100, 0x7070200, 0xe0e0009,
+		0x1c0c2412, 0x38086c24, 0x3090fc48, 0x21b1f890, 0x3f3f120,
+	}
+	chk := 1
+	step := func(v int) {
+		b := chk >> 20
+		chk = (chk&0xfffff)<<10 ^ v
+		for i := 0; i < 10; i++ {
+			if b>>i&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	for _, c := range []byte(customization) {
+		step(int(c))
+	}
+	for _, v := range data {
+		step(v)
+	}
+	return chk
+}
@@ -0,0 +1,142 @@
+package slip39
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestWordlist(t *testing.T) {
+	if !sort.StringsAreSorted(wordlist[:]) {
+		t.Fatalf("wordlist not sorted")
+	}
+	if len(wordIndex) != len(wordlist) {
+		t.Fatalf("prefixes not unique")
+	}
+	for _, w := range wordlist {
+		if len(w) < 4 || len(w) > 8 {
+			t.Fatalf("bad: %s", w)
+		}
+	}
+}
+
+func TestParseShare(t *testing.T) {
+	m := "duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision keyboard"
+
+	s, err := ParseShare(m)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if s.Identifier != 7945 || s.Extendable || s.IterationExponent != 0 || s.GroupThreshold != 1 || s.GroupCount != 1 || s.MemberThreshold != 1 || len(s.Value) != 16 {
+		t.Fatalf("bad: %+v", s)
+	}
+
+	out, err := s.Mnemonic()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != m {
+		t.Fatalf("bad: %s", out)
+	}
+
+	// Words may be abbreviated to their first four letters.
+	abbr := make([]string, 0, 20)
+	for _, w := range strings.Fields(m) {
+		abbr = append(abbr, strings.ToUpper(w[:4]))
+	}
+	s2, err := ParseShare(strings.Join(abbr, "  "))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(s2.Value, s.Value) {
+		t.Fatalf("bad: %+v", s2)
+	}
+}
+
+func TestParseShare_invalid(t *testing.T) {
+	tests := map[string]string{
+		// Last word changed.
+		"checksum": "duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision kidney",
+		"short":    "duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision",
+		"unknown":  "duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision keyboardx",
+		"prefix":   "duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision key",
+	}
+	for name, m := range tests {
+		if _, err := ParseShare(m); err == nil {
+			t.Errorf("%s: expect error", name)
+		}
+	}
+
+	m := tests["checksum"]
+	if _, err := ParseShare(m); err != ErrInvalidChecksum {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestShare_Mnemonic(t *testing.T) {
+	valid := Share{
+		Identifier:        maxIdentifierValue,
+		Extendable:        true,
+		IterationExponent: maxIterationExp,
+		GroupIndex:        15,
+		GroupThreshold:    16,
+		GroupCount:        16,
+		MemberIndex:       15,
+		MemberThreshold:   16,
+		Value:             bytes.Repeat([]byte{0xff}, 32),
+	}
+	m, err := valid.Mnemonic()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(strings.Fields(m)) != 33 {
+		t.Fatalf("bad: %s", m)
+	}
+	s, err := ParseShare(m)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if s.Identifier != valid.Identifier || !s.Extendable || s.IterationExponent != valid.IterationExponent ||
+		s.GroupIndex != 15 || s.GroupThreshold != 16 || s.GroupCount != 16 || s.MemberIndex != 15 ||
+		s.MemberThreshold != 16 || !bytes.Equal(s.Value, valid.Value) {
+		t.Fatalf("bad: %+v", s)
+	}
+
+	tests := map[string]func(s *Share){
+		"identifier": func(s *Share) { s.Identifier = maxIdentifierValue + 1 },
+		"exponent":   func(s *Share) { s.IterationExponent = 16 },
+		"group":      func(s *Share) { s.GroupThreshold = 17 },
+		"index":      func(s *Share) { s.GroupIndex = 16 },
+		"member":     func(s *Share) { s.MemberThreshold = 0 },
+		"value":      func(s *Share) { s.Value = s.Value[:15] },
+	}
+	for name, mutate := range tests {
+		s := valid
+		mutate(&s)
+		if _, err := s.Mnemonic(); err == nil {
+			t.Errorf("%s: expect error", name)
+		}
+	}
+}
+
+func TestWords(t *testing.T) {
+	for _, n := range []int{16, 18, 32, 64} {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = byte(255 - i)
+		}
+		words := toWords(b)
+		out, err := fromWords(words, 10*len(words)-8*n)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !bytes.Equal(out, b) {
+			t.Fatalf("%d: bad: %v", n, out)
+		}
+	}
+
+	if _, err := fromWords([]int{0x3ff, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 2); err == nil {
+		t.Fatalf("expect error")
+	}
+}
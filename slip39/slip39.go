@@ -0,0 +1,334 @@
+// Package slip39 implements SLIP-0039, Shamir's secret-sharing for mnemonic
+// codes, as used by hardware wallets.
+//
+// The master secret is encrypted with a passphrase and split in two levels:
+// into groups, a threshold of which is required, and each group into member
+// shares, a threshold of which is required to recover the group. Shares are
+// encoded as mnemonics of the SLIP-0039 wordlist, protected by a checksum.
+// The shares are computed in GF(2^8) with the AES reduction polynomial,
+// shamir.AESField.
+package slip39
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/corvus-ch/shamir"
+)
+
+// ErrInvalidDigest is returned by Combine if the shares do not reconstruct the
+// secret they were split from.
+var ErrInvalidDigest = errors.New("invalid digest of the shared secret")
+
+const (
+	digestLength = 4
+	digestIndex  = 254
+	secretIndex  = 255
+)
+
+// Option configures the optional behaviour of Split.
+type Option func(*config)
+
+type config struct {
+	rand       io.Reader
+	exp        int
+	extendable bool
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{rand: rand.Reader, exp: 1, extendable: true}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithRand sets the source of randomness used to pick the identifier and to
+// split the secret. It defaults to crypto/rand.Reader.
+func WithRand(r io.Reader) Option {
+	return func(c *config) {
+		c.rand = r
+	}
+}
+
+// WithIterationExponent sets the iteration exponent, raising the number of
+// PBKDF2 iterations of the passphrase encryption to 10000·2^exp. It defaults
+// to 1.
+func WithIterationExponent(exp int) Option {
+	return func(c *config) {
+		c.exp = exp
+	}
+}
+
+// WithExtendable sets whether the shares are extendable, allowing to create
+// further share sets for the same master secret and passphrase. It defaults
+// to true. Shares created by implementations of earlier revisions of
+// SLIP-0039 are not extendable.
+func WithExtendable(extendable bool) Option {
+	return func(c *config) {
+		c.extendable = extendable
+	}
+}
+
+// Group describes a group of member shares, `Threshold` of the `Count`
+// members are required to recover the group.
+type Group struct {
+	Threshold int
+	Count     int
+}
+
+// Split encrypts the master secret with the passphrase and splits it into the
+// given groups, `groupThreshold` of which are required to recover the master
+// secret. The master secret must be an even number of at least 16 bytes. The
+// mnemonics are returned per group.
+func Split(masterSecret []byte, groupThreshold int, groups []Group, passphrase []byte, opts ...Option) ([][]string, error) {
+	cfg := newConfig(opts)
+	if 8*len(masterSecret) < minStrengthBits || len(masterSecret)%2 != 0 {
+		return nil, fmt.Errorf("master secret must be an even number of at least %d bytes", minStrengthBits/8)
+	}
+	if err := checkPassphrase(passphrase); nil != err {
+		return nil, err
+	}
+	if groupThreshold < 1 || groupThreshold > len(groups) {
+		return nil, fmt.Errorf("group threshold must be between 1 and the number of groups")
+	}
+	if len(groups) > maxShareCount {
+		return nil, fmt.Errorf("number of groups cannot exceed %d", maxShareCount)
+	}
+	for _, g := range groups {
+		if g.Threshold < 1 || g.Threshold > g.Count || g.Count > maxShareCount {
+			return nil, fmt.Errorf("member threshold must be between 1 and the member count of at most %d", maxShareCount)
+		}
+		if g.Threshold == 1 && g.Count > 1 {
+			return nil, fmt.Errorf("multiple member shares with member threshold 1 are not allowed, use 1-of-1 member sharing instead")
+		}
+	}
+	if cfg.exp < 0 || cfg.exp > maxIterationExp {
+		return nil, fmt.Errorf("iteration exponent must be between 0 and %d", maxIterationExp)
+	}
+
+	var buf [2]byte
+	if _, err := io.ReadFull(cfg.rand, buf[:]); nil != err {
+		return nil, fmt.Errorf("failed to generate identifier: %v", err)
+	}
+	id := (uint16(buf[0])<<8 | uint16(buf[1])) & maxIdentifierValue
+
+	ems := encrypt(masterSecret, passphrase, cfg.exp, id, cfg.extendable)
+	groupSecrets, err := splitSecret(groupThreshold, len(groups), ems, cfg.rand)
+	if nil != err {
+		return nil, err
+	}
+
+	out := make([][]string, len(groups))
+	for i, g := range groups {
+		values, err := splitSecret(g.Threshold, g.Count, groupSecrets[i], cfg.rand)
+		if nil != err {
+			return nil, err
+		}
+		for j, v := range values {
+			s := Share{
+				Identifier:        id,
+				Extendable:        cfg.extendable,
+				IterationExponent: cfg.exp,
+				GroupIndex:        i,
+				GroupThreshold:    groupThreshold,
+				GroupCount:        len(groups),
+				MemberIndex:       j,
+				MemberThreshold:   g.Threshold,
+				Value:             v,
+			}
+			m, err := s.Mnemonic()
+			if nil != err {
+				return nil, err
+			}
+			out[i] = append(out[i], m)
+		}
+	}
+
+	return out, nil
+}
+
+// Combine recovers the master secret from the mnemonics and decrypts it with
+// the passphrase. Exactly the threshold number of groups must be given, each
+// with exactly its threshold number of member shares. Any passphrase results
+// in a master secret, only the correct one in the original.
+func Combine(mnemonics []string, passphrase []byte) ([]byte, error) {
+	if len(mnemonics) == 0 {
+		return nil, fmt.Errorf("the set of shares is empty")
+	}
+	if err := checkPassphrase(passphrase); nil != err {
+		return nil, err
+	}
+
+	shares := make([]*Share, len(mnemonics))
+	for i, m := range mnemonics {
+		s, err := ParseShare(m)
+		if nil != err {
+			return nil, err
+		}
+		shares[i] = s
+	}
+
+	first := shares[0]
+	groups := make(map[int][]*Share)
+	for _, s := range shares {
+		if s.Identifier != first.Identifier || s.Extendable != first.Extendable ||
+			s.IterationExponent != first.IterationExponent || s.GroupThreshold != first.GroupThreshold ||
+			s.GroupCount != first.GroupCount || len(s.Value) != len(first.Value) {
+			return nil, fmt.Errorf("all mnemonics must begin with the same %d words, must have the same group threshold, group count and length", idExpWords)
+		}
+		members, err := addMember(groups[s.GroupIndex], s)
+		if nil != err {
+			return nil, err
+		}
+		groups[s.GroupIndex] = members
+	}
+
+	if len(groups) != first.GroupThreshold {
+		return nil, fmt.Errorf("wrong number of mnemonic groups, expected %d groups, but %d were provided", first.GroupThreshold, len(groups))
+	}
+
+	indexes := make([]int, 0, len(groups))
+	for gi := range groups {
+		indexes = append(indexes, gi)
+	}
+	sort.Ints(indexes)
+
+	xs := make([]byte, 0, len(groups))
+	ys := make([][]byte, 0, len(groups))
+	for _, gi := range indexes {
+		members := groups[gi]
+		threshold := members[0].MemberThreshold
+		if len(members) != threshold {
+			return nil, fmt.Errorf("wrong number of mnemonics in group %d, expected %d mnemonics, but %d were provided", gi, threshold, len(members))
+		}
+
+		mxs := make([]byte, len(members))
+		mys := make([][]byte, len(members))
+		for i, m := range members {
+			mxs[i] = byte(m.MemberIndex)
+			mys[i] = m.Value
+		}
+		secret, err := recoverSecret(threshold, mxs, mys)
+		if nil != err {
+			return nil, err
+		}
+		xs = append(xs, byte(gi))
+		ys = append(ys, secret)
+	}
+
+	ems, err := recoverSecret(first.GroupThreshold, xs, ys)
+	if nil != err {
+		return nil, err
+	}
+
+	return decrypt(ems, passphrase, first.IterationExponent, first.Identifier, first.Extendable), nil
+}
+
+// addMember adds s to the members of its group, ignoring duplicates.
+func addMember(members []*Share, s *Share) ([]*Share, error) {
+	for _, m := range members {
+		if m.MemberThreshold != s.MemberThreshold {
+			return nil, fmt.Errorf("mnemonics of group %d have different member thresholds", s.GroupIndex)
+		}
+		if m.MemberIndex == s.MemberIndex {
+			if !hmac.Equal(m.Value, s.Value) {
+				return nil, fmt.Errorf("mnemonics of group %d have the same member index but different values", s.GroupIndex)
+			}
+			return members, nil
+		}
+	}
+	return append(members, s), nil
+}
+
+// checkPassphrase ensures the passphrase consists of printable ASCII
+// characters only.
+func checkPassphrase(passphrase []byte) error {
+	for _, c := range passphrase {
+		if c < 32 || c > 126 {
+			return fmt.Errorf("passphrase must only contain printable ASCII characters")
+		}
+	}
+	return nil
+}
+
+// splitSecret splits the secret into count shares, threshold of which are
+// required to recover it. Besides the secret at x = 255, the polynomial passes
+// through a digest of the secret at x = 254, which allows to verify the
+// recovered secret.
+func splitSecret(threshold, count int, secret []byte, r io.Reader) ([][]byte, error) {
+	shares := make([][]byte, count)
+	if threshold == 1 {
+		for i := range shares {
+			shares[i] = append([]byte(nil), secret...)
+		}
+		return shares, nil
+	}
+
+	randomCount := threshold - 2
+	xs := make([]byte, 0, threshold)
+	ys := make([][]byte, 0, threshold)
+	for i := 0; i < randomCount; i++ {
+		shares[i] = make([]byte, len(secret))
+		if _, err := io.ReadFull(r, shares[i]); nil != err {
+			return nil, fmt.Errorf("failed to generate share: %v", err)
+		}
+		xs = append(xs, byte(i))
+		ys = append(ys, shares[i])
+	}
+
+	digest := make([]byte, len(secret))
+	if _, err := io.ReadFull(r, digest[digestLength:]); nil != err {
+		return nil, fmt.Errorf("failed to generate share: %v", err)
+	}
+	copy(digest, createDigest(digest[digestLength:], secret))
+	xs = append(xs, digestIndex, secretIndex)
+	ys = append(ys, digest, secret)
+
+	for i := randomCount; i < count; i++ {
+		shares[i] = interpolate(xs, ys, byte(i))
+	}
+
+	return shares, nil
+}
+
+// recoverSecret reverses splitSecret given threshold shares and verifies the
+// digest of the recovered secret.
+func recoverSecret(threshold int, xs []byte, ys [][]byte) ([]byte, error) {
+	if threshold == 1 {
+		return ys[0], nil
+	}
+
+	secret := interpolate(xs, ys, secretIndex)
+	digest := interpolate(xs, ys, digestIndex)
+	if !hmac.Equal(digest[:digestLength], createDigest(digest[digestLength:], secret)) {
+		return nil, ErrInvalidDigest
+	}
+
+	return secret, nil
+}
+
+func createDigest(random, secret []byte) []byte {
+	mac := hmac.New(sha256.New, random)
+	mac.Write(secret)
+	return mac.Sum(nil)[:digestLength]
+}
+
+// interpolate returns the values at x of the polynomials passing through the
+// points given by xs and ys, one polynomial per byte.
+func interpolate(xs []byte, ys [][]byte, x byte) []byte {
+	out := make([]byte, len(ys[0]))
+	col := make([]byte, len(ys))
+	for i := range out {
+		for j, y := range ys {
+			col[j] = y[i]
+		}
+		out[i] = shamir.AESField.Interpolate(xs, col, x)
+	}
+	return out
+}
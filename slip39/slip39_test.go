@@ -0,0 +1,257 @@
+package slip39
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// vector is an entry of testdata/vectors.json, the official SLIP-0039 test
+// vectors: a description, the mnemonics, the master secret in hex, empty for
+// invalid mnemonics, and the BIP-0032 master extended private key.
+type vector struct {
+	description string
+	mnemonics   []string
+	secret      string
+}
+
+func (v *vector) UnmarshalJSON(data []byte) error {
+	var xprv string
+	return json.Unmarshal(data, &[]interface{}{&v.description, &v.mnemonics, &v.secret, &xprv})
+}
+
+func loadVectors(t *testing.T) []vector {
+	data, err := os.ReadFile("testdata/vectors.json")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var vectors []vector
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return vectors
+}
+
+// vectorErrors holds the error expected for each invalid vector, keyed by the
+// number the description starts with.
+var vectorErrors = map[int]error{
+	2:  ErrInvalidChecksum,
+	3:  errors.New("invalid mnemonic padding"),
+	5:  errors.New("wrong number of mnemonics in group 0, expected 2 mnemonics, but 1 were provided"),
+	6:  errors.New("all mnemonics must begin with the same 2 words, must have the same group threshold, group count and length"),
+	7:  errors.New("all mnemonics must begin with the same 2 words, must have the same group threshold, group count and length"),
+	8:  errors.New("all mnemonics must begin with the same 2 words, must have the same group threshold, group count and length"),
+	9:  errors.New("all mnemonics must begin with the same 2 words, must have the same group threshold, group count and length"),
+	10: errors.New("group threshold cannot exceed group count"),
+	11: errors.New("mnemonics of group 0 have the same member index but different values"),
+	12: errors.New("mnemonics of group 0 have different member thresholds"),
+	13: ErrInvalidDigest,
+	14: errors.New("wrong number of mnemonic groups, expected 2 groups, but 1 were provided"),
+	15: errors.New("wrong number of mnemonic groups, expected 2 groups, but 1 were provided"),
+	16: errors.New("wrong number of mnemonics in group 3, expected 2 mnemonics, but 1 were provided"),
+	21: ErrInvalidChecksum,
+	22: errors.New("invalid mnemonic padding"),
+	24: errors.New("wrong number of mnemonics in group 0, expected 2 mnemonics, but 1 were provided"),
+	25: errors.New("all mnemonics must begin with the same 2 words, must have the same group threshold, group count and length"),
+	26: errors.New("all mnemonics must begin with the same 2 words, must have the same group threshold, group count and length"),
+	27: errors.New("all mnemonics must begin with the same 2 words, must have the same group threshold, group count and length"),
+	28: errors.New("all mnemonics must begin with the same 2 words, must have the same group threshold, group count and length"),
+	29: errors.New("group threshold cannot exceed group count"),
+	30: errors.New("mnemonics of group 0 have the same member index but different values"),
+	31: errors.New("mnemonics of group 0 have different member thresholds"),
+	32: ErrInvalidDigest,
+	33: errors.New("wrong number of mnemonic groups, expected 2 groups, but 1 were provided"),
+	34: errors.New("wrong number of mnemonic groups, expected 2 groups, but 1 were provided"),
+	35: errors.New("wrong number of mnemonics in group 3, expected 2 mnemonics, but 1 were provided"),
+	39: errors.New("mnemonic must be at least 20 words long"),
+	40: errors.New("invalid mnemonic length"),
+}
+
+func TestCombine_vectors(t *testing.T) {
+	vectors := loadVectors(t)
+	if len(vectors) != 45 {
+		t.Fatalf("bad: %d vectors", len(vectors))
+	}
+
+	for i, v := range vectors {
+		n := i + 1
+		if !strings.HasPrefix(v.description, strconv.Itoa(n)+". ") {
+			t.Fatalf("bad: %s", v.description)
+		}
+
+		out, err := Combine(v.mnemonics, []byte("TREZOR"))
+		if v.secret == "" {
+			exp, ok := vectorErrors[n]
+			if !ok {
+				t.Fatalf("%s: no expected error", v.description)
+			}
+			if err == nil || err.Error() != exp.Error() {
+				t.Errorf("%s: unexpected error: %v", v.description, err)
+			}
+			if (exp == ErrInvalidChecksum || exp == ErrInvalidDigest) && err != exp {
+				t.Errorf("%s: unexpected error: %v", v.description, err)
+			}
+			continue
+		}
+		if _, ok := vectorErrors[n]; ok {
+			t.Fatalf("%s: unexpected expected error", v.description)
+		}
+		if err != nil {
+			t.Errorf("%s: err: %v", v.description, err)
+			continue
+		}
+		if hex.EncodeToString(out) != v.secret {
+			t.Errorf("%s: bad: %x", v.description, out)
+		}
+
+		// The shares encode to the very same mnemonics, which covers the
+		// checksum customization of extendable shares.
+		for _, m := range v.mnemonics {
+			s, err := ParseShare(m)
+			if err != nil {
+				t.Fatalf("%s: err: %v", v.description, err)
+			}
+			if s.Extendable != strings.Contains(strings.ToLower(v.description), "extendable") {
+				t.Errorf("%s: bad extendable flag", v.description)
+			}
+			if out, err := s.Mnemonic(); err != nil || out != m {
+				t.Errorf("%s: bad: %q %v", v.description, out, err)
+			}
+		}
+	}
+}
+
+func TestCombine_invalid(t *testing.T) {
+	vectors := loadVectors(t)
+	basic := vectors[3].mnemonics
+	groups := []string{
+		"eraser senior beard romp adorn nuclear spill corner cradle style ancient family general leader ambition exchange unusual garlic promise voice",
+		"eraser senior ceramic snake clay various huge numb argue hesitate auction category timber browser greatest hanger petition script leaf pickup",
+		"eraser senior ceramic shaft dynamic become junior wrist silver peasant force math alto coal amazing segment yelp velvet image paces",
+		"eraser senior ceramic round column hawk trust auction smug shame alive greatest sheriff living perfect corner chest sled fumes adequate",
+	}
+	tests := map[string][]string{
+		"empty":         nil,
+		"checksum":      {"duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision kidney"},
+		"insufficient":  basic[:1],
+		"mixed sets":    {basic[0], vectors[0].mnemonics[0]},
+		"missing group": groups[:1],
+		"extra group": append(append([]string(nil), groups...),
+			"eraser senior decision smug corner ruin rescue cubic angel tackle skin skunk program roster trash rumor slush angel flea amazing"),
+		"missing member": groups[:3],
+	}
+	for name, mnemonics := range tests {
+		if _, err := Combine(mnemonics, []byte("TREZOR")); err == nil {
+			t.Errorf("%s: expect error", name)
+		}
+	}
+
+	if _, err := Combine(vectors[0].mnemonics, []byte("TRÉZOR")); err == nil {
+		t.Fatalf("expect error")
+	}
+}
+
+func TestCombine_duplicate(t *testing.T) {
+	vectors := loadVectors(t)
+	basic := vectors[3].mnemonics
+	out, err := Combine([]string{basic[0], basic[1], basic[0]}, []byte("TREZOR"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if hex.EncodeToString(out) != vectors[3].secret {
+		t.Fatalf("bad: %x", out)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	ms := []byte("ABCDEFGHIJKLMNOPQRSTUVWXYZ012345")
+	groups := []Group{{1, 1}, {2, 3}, {3, 5}}
+
+	for _, extendable := range []bool{true, false} {
+		out, err := Split(ms, 2, groups, []byte("TREZOR"), WithIterationExponent(0), WithExtendable(extendable))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if len(out) != 3 || len(out[0]) != 1 || len(out[1]) != 3 || len(out[2]) != 5 {
+			t.Fatalf("bad: %v", out)
+		}
+
+		mnemonics := []string{out[2][4], out[2][0], out[1][2], out[2][2], out[1][0]}
+		recomb, err := Combine(mnemonics, []byte("TREZOR"))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !bytes.Equal(recomb, ms) {
+			t.Fatalf("bad: %q", recomb)
+		}
+
+		recomb, err = Combine([]string{out[0][0], out[1][1], out[1][2]}, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if bytes.Equal(recomb, ms) {
+			t.Fatalf("expect different result")
+		}
+
+		s, err := ParseShare(out[1][1])
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if s.Extendable != extendable || s.GroupIndex != 1 || s.MemberIndex != 1 || s.MemberThreshold != 2 || s.GroupThreshold != 2 || s.GroupCount != 3 {
+			t.Fatalf("bad: %+v", s)
+		}
+	}
+}
+
+func TestSplit_digest(t *testing.T) {
+	out, err := Split([]byte("ABCDEFGHIJKLMNOP"), 1, []Group{{2, 3}}, nil, WithIterationExponent(0))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Tampering with a share value is detected by the digest.
+	s, err := ParseShare(out[0][0])
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	s.Value[0] ^= 1
+	m, err := s.Mnemonic()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := Combine([]string{m, out[0][1]}, nil); err != ErrInvalidDigest {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSplit_invalid(t *testing.T) {
+	ms := []byte("ABCDEFGHIJKLMNOP")
+	tests := map[string]struct {
+		ms         []byte
+		threshold  int
+		groups     []Group
+		passphrase []byte
+		opts       []Option
+	}{
+		"short secret":     {ms[:14], 1, []Group{{1, 1}}, nil, nil},
+		"odd secret":       {append(ms, 'Q'), 1, []Group{{1, 1}}, nil, nil},
+		"passphrase":       {ms, 1, []Group{{1, 1}}, []byte{0}, nil},
+		"group threshold":  {ms, 2, []Group{{1, 1}}, nil, nil},
+		"zero threshold":   {ms, 0, []Group{{1, 1}}, nil, nil},
+		"member threshold": {ms, 1, []Group{{3, 2}}, nil, nil},
+		"member count":     {ms, 1, []Group{{2, 17}}, nil, nil},
+		"one of many":      {ms, 1, []Group{{1, 2}}, nil, nil},
+		"exponent":         {ms, 1, []Group{{1, 1}}, nil, []Option{WithIterationExponent(16)}},
+		"groups":           {ms, 1, make([]Group, 17), nil, nil},
+	}
+	for name, test := range tests {
+		if _, err := Split(test.ms, test.threshold, test.groups, test.passphrase, test.opts...); err == nil {
+			t.Errorf("%s: expect error", name)
+		}
+	}
+}
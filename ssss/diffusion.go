@@ -0,0 +1,86 @@
+package ssss
+
+// The diffusion layer of ssss spreads every bit of the secret over the whole
+// secret before it is split, so that a share set leaking individual bits of
+// the secret does not leak bits of the original secret. It applies a 64 bit
+// pseudo random permutation, XTEA with an all zero key, to overlapping slices
+// of the secret. It requires a security level of at least 64 bits.
+
+// minDiffusionDegree is the smallest security level the diffusion layer is
+// applied to.
+const minDiffusionDegree = 64
+
+const delta = 0x9e3779b9
+
+func encipherBlock(v *[2]uint32) {
+	var sum uint32
+	for i := 0; i < 32; i++ {
+		v[0] += (((v[1] << 4) ^ (v[1] >> 5)) + v[1]) ^ sum
+		sum += delta
+		v[1] += (((v[0] << 4) ^ (v[0] >> 5)) + v[0]) ^ sum
+	}
+}
+
+func decipherBlock(v *[2]uint32) {
+	var sum uint32 = 0xc6ef3720 // delta * 32
+	for i := 0; i < 32; i++ {
+		v[1] -= (((v[0] << 4) ^ (v[0] >> 5)) + v[0]) ^ sum
+		sum -= delta
+		v[0] -= (((v[1] << 4) ^ (v[1] >> 5)) + v[1]) ^ sum
+	}
+}
+
+// processSlice applies process to the 8 bytes of data starting at idx,
+// wrapping around at the end of data.
+func processSlice(data []byte, idx int, process func(*[2]uint32)) {
+	n := len(data)
+	var v [2]uint32
+	for i := range v {
+		for j := 0; j < 4; j++ {
+			v[i] = v[i]<<8 | uint32(data[(idx+4*i+j)%n])
+		}
+	}
+	process(&v)
+	for i := range v {
+		for j := 0; j < 4; j++ {
+			data[(idx+4*i+j)%n] = byte(v[i] >> (24 - 8*j))
+		}
+	}
+}
+
+// diffuse applies the diffusion layer to the big endian secret b in place, or
+// reverts it if decode is set.
+//
+// ssss exports the secret as 16 bit words, least significant word first and
+// most significant byte first within each word. Of an odd number of bytes,
+// the most significant byte fills the last position.
+func diffuse(b []byte, decode bool) {
+	n := len(b)
+	v := make([]byte, n)
+	for i := 0; i+1 < n; i += 2 {
+		v[i] = b[n-2-i]
+		v[i+1] = b[n-1-i]
+	}
+	if n%2 == 1 {
+		v[n-1] = b[0]
+	}
+
+	rounds := 40 * n
+	if decode {
+		for i := rounds - 2; i >= 0; i -= 2 {
+			processSlice(v, i, decipherBlock)
+		}
+	} else {
+		for i := 0; i < rounds; i += 2 {
+			processSlice(v, i, encipherBlock)
+		}
+	}
+
+	for i := 0; i+1 < n; i += 2 {
+		b[n-2-i] = v[i]
+		b[n-1-i] = v[i+1]
+	}
+	if n%2 == 1 {
+		b[0] = v[n-1]
+	}
+}
@@ -0,0 +1,52 @@
+package ssss
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBlock(t *testing.T) {
+	v := [2]uint32{0x01234567, 0x89abcdef}
+	encipherBlock(&v)
+	if v == [2]uint32{0x01234567, 0x89abcdef} {
+		t.Fatalf("block not changed")
+	}
+	decipherBlock(&v)
+	if v != [2]uint32{0x01234567, 0x89abcdef} {
+		t.Fatalf("bad: %x", v)
+	}
+}
+
+func TestDiffuse(t *testing.T) {
+	for _, n := range []int{8, 9, 23, 128} {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = byte(i)
+		}
+		orig := append([]byte(nil), b...)
+
+		diffuse(b, false)
+		if bytes.Equal(b, orig) {
+			t.Fatalf("%d: not diffused", n)
+		}
+
+		// Flipping a single bit changes about half of the bits.
+		flipped := append([]byte(nil), orig...)
+		flipped[0] ^= 1
+		diffuse(flipped, false)
+		changed := 0
+		for i := range b {
+			for d := b[i] ^ flipped[i]; d != 0; d &= d - 1 {
+				changed++
+			}
+		}
+		if changed < 2*n || changed > 6*n {
+			t.Fatalf("%d: bad diffusion: %d bits changed", n, changed)
+		}
+
+		diffuse(b, true)
+		if !bytes.Equal(b, orig) {
+			t.Fatalf("%d: bad: %v", n, b)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package ssss
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// maxDegree is the largest field supported by ssss, limiting secrets to 128
+// bytes.
+const maxDegree = 1024
+
+// irredCoeff holds the middle coefficients of the irreducible pentanomials
+// x^deg + x^a + x^b + x^c + 1 used by ssss, three per degree from 8 to 1024
+// in steps of 8.
+var irredCoeff = [3 * maxDegree / 8]int{
+	4, 3, 1, 5, 3, 1, 4, 3, 1, 7, 3, 2, 5, 4, 3, 5, 3, 2, 7, 4, 2, 4, 3, 1, 10, 9, 3, 9, 4, 2, 7, 6, 2, 10, 9,
+	6, 4, 3, 1, 5, 4, 3, 4, 3, 1, 7, 2, 1, 5, 3, 2, 7, 4, 2, 6, 3, 2, 5, 3, 2, 15, 3, 2, 11, 3, 2, 9, 8, 7, 7,
+	2, 1, 5, 3, 2, 9, 3, 1, 7, 3, 1, 9, 8, 3, 9, 4, 2, 8, 5, 3, 15, 14, 10, 10, 5, 2, 9, 6, 2, 9, 3, 2, 9, 5,
+	2, 11, 10, 1, 7, 3, 2, 11, 2, 1, 9, 7, 4, 4, 3, 1, 8, 3, 1, 7, 4, 1, 7, 2, 1, 13, 11, 6, 5, 3, 2, 7, 3, 2,
+	8, 7, 5, 12, 3, 2, 13, 10, 6, 5, 3, 2, 5, 3, 2, 9, 5, 2, 9, 7, 2, 13, 4, 3, 4, 3, 1, 11, 6, 4, 18, 9, 6,
+	19, 18, 13, 11, 3, 2, 15, 9, 6, 4, 3, 1, 16, 5, 2, 15, 14, 6, 8, 5, 2, 15, 11, 2, 11, 6, 2, 7, 5, 3, 8,
+	3, 1, 19, 16, 9, 11, 9, 6, 15, 7, 6, 13, 4, 3, 14, 13, 3, 13, 6, 3, 9, 5, 2, 19, 13, 6, 19, 10, 3, 11,
+	6, 5, 9, 2, 1, 14, 3, 2, 13, 3, 1, 7, 5, 4, 11, 9, 8, 11, 6, 5, 23, 16, 9, 19, 14, 6, 23, 10, 2, 8, 3,
+	2, 5, 4, 3, 9, 6, 4, 4, 3, 2, 13, 8, 6, 13, 11, 1, 13, 10, 3, 11, 6, 5, 19, 17, 4, 15, 14, 7, 13, 9, 6,
+	9, 7, 3, 9, 7, 1, 14, 3, 2, 11, 8, 2, 11, 6, 4, 13, 5, 2, 11, 5, 1, 11, 4, 1, 19, 10, 3, 21, 10, 6, 13,
+	3, 1, 15, 7, 5, 19, 18, 10, 7, 5, 3, 12, 7, 2, 7, 5, 1, 14, 9, 6, 10, 3, 2, 15, 13, 12, 12, 11, 9, 16,
+	9, 7, 12, 9, 3, 9, 5, 2, 17, 10, 6, 24, 9, 3, 17, 15, 13, 5, 4, 3, 19, 17, 8, 15, 6, 3, 19, 6, 1,
+}
+
+// field is GF(2^degree) with the reduction polynomial of ssss. Elements are
+// represented as big integers, bit i being the coefficient of x^i.
+type field struct {
+	degree int
+	poly   *big.Int
+}
+
+// newField returns the field of the given degree, which must be a multiple of
+// 8 between 8 and 1024.
+func newField(degree int) (*field, error) {
+	if degree < 8 || degree > maxDegree || degree%8 != 0 {
+		return nil, fmt.Errorf("security level must be a multiple of 8 between 8 and %d", maxDegree)
+	}
+
+	poly := new(big.Int)
+	poly.SetBit(poly, degree, 1)
+	for _, c := range irredCoeff[3*(degree/8-1) : 3*(degree/8)] {
+		poly.SetBit(poly, c, 1)
+	}
+	poly.SetBit(poly, 0, 1)
+
+	return &field{degree: degree, poly: poly}, nil
+}
+
+// add returns x + y.
+func (f *field) add(x, y *big.Int) *big.Int {
+	return new(big.Int).Xor(x, y)
+}
+
+// mul returns x * y.
+func (f *field) mul(x, y *big.Int) *big.Int {
+	b := new(big.Int).Set(x)
+	z := new(big.Int)
+	for i := 0; i < f.degree; i++ {
+		if y.Bit(i) == 1 {
+			z.Xor(z, b)
+		}
+		b.Lsh(b, 1)
+		if b.Bit(f.degree) == 1 {
+			b.Xor(b, f.poly)
+		}
+	}
+	return z
+}
+
+// inv returns the multiplicative inverse of x, which must not be zero, using
+// the extended Euclidean algorithm.
+func (f *field) inv(x *big.Int) *big.Int {
+	u := new(big.Int).Set(x)
+	v := new(big.Int).Set(f.poly)
+	z := big.NewInt(1)
+	g := new(big.Int)
+	h := new(big.Int)
+	for u.Cmp(big.NewInt(1)) != 0 {
+		i := u.BitLen() - v.BitLen()
+		if i < 0 {
+			u, v = v, u
+			z, g = g, z
+			i = -i
+		}
+		u.Xor(u, h.Lsh(v, uint(i)))
+		z.Xor(z, h.Lsh(g, uint(i)))
+	}
+	return z
+}
@@ -0,0 +1,91 @@
+package ssss
+
+import (
+	"math/big"
+	"testing"
+)
+
+// polyMod returns a mod b for polynomials over GF(2).
+func polyMod(a, b *big.Int) *big.Int {
+	a = new(big.Int).Set(a)
+	h := new(big.Int)
+	for a.BitLen() >= b.BitLen() {
+		a.Xor(a, h.Lsh(b, uint(a.BitLen()-b.BitLen())))
+	}
+	return a
+}
+
+// polyGCD returns the greatest common divisor of polynomials over GF(2).
+func polyGCD(a, b *big.Int) *big.Int {
+	for b.Sign() != 0 {
+		a, b = b, polyMod(a, b)
+	}
+	return a
+}
+
+// TestField_irreducible checks the reduction polynomials with Rabin's test:
+// a polynomial of degree n is irreducible if x^(2^n) = x mod f and
+// gcd(x^(2^(n/p)) - x, f) = 1 for every prime p dividing n.
+func TestField_irreducible(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode")
+	}
+
+	x := big.NewInt(2)
+	for degree := 8; degree <= maxDegree; degree += 8 {
+		f, err := newField(degree)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// powers[i] = x^(2^i) mod f
+		powers := make([]*big.Int, degree+1)
+		powers[0] = x
+		for i := 1; i <= degree; i++ {
+			powers[i] = f.mul(powers[i-1], powers[i-1])
+		}
+		if powers[degree].Cmp(x) != 0 {
+			t.Fatalf("%d: polynomial is reducible", degree)
+		}
+		for p := 2; p <= degree; p++ {
+			if degree%p != 0 || !big.NewInt(int64(p)).ProbablyPrime(0) {
+				continue
+			}
+			if polyGCD(f.poly, f.add(powers[degree/p], x)).Cmp(big.NewInt(1)) != 0 {
+				t.Fatalf("%d: polynomial is reducible", degree)
+			}
+		}
+	}
+}
+
+func TestField_inv(t *testing.T) {
+	for _, degree := range []int{8, 64, 184, 1024} {
+		f, err := newField(degree)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		for _, v := range []int64{1, 2, 3, 0x53, 0xcafe} {
+			x := big.NewInt(v)
+			if x.BitLen() > degree {
+				continue
+			}
+			if out := f.mul(x, f.inv(x)); out.Cmp(big.NewInt(1)) != 0 {
+				t.Fatalf("%d: bad: %v", degree, out)
+			}
+		}
+	}
+
+	// The field of degree 8 is the field of AES.
+	f, _ := newField(8)
+	if out := f.mul(big.NewInt(0x57), big.NewInt(0x83)); out.Int64() != 0xc1 {
+		t.Fatalf("bad: %x", out)
+	}
+}
+
+func TestNewField_invalid(t *testing.T) {
+	for _, degree := range []int{0, 4, 12, 1032} {
+		if _, err := newField(degree); err == nil {
+			t.Errorf("%d: expect error", degree)
+		}
+	}
+}
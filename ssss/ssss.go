@@ -0,0 +1,279 @@
+// Package ssss splits and combines secrets compatible with ssss-split and
+// ssss-combine of B. Poettering's ssss tool.
+//
+// Shares are text lines of the form `[token-]index-hexdata`. The index is the
+// x coordinate, zero padded to the number of digits of the number of shares.
+// The shares are computed in GF(2^(8·len)), where len is the length of the
+// secret in bytes unless a security level is set. By default the secret
+// passes a diffusion layer before it is split, just like ssss does unless
+// called with -D.
+package ssss
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// maxTokenLen is the longest token accepted by ssss.
+const maxTokenLen = 128
+
+// Option configures the optional behaviour of Split and Combine.
+type Option func(*config)
+
+type config struct {
+	rand      io.Reader
+	token     string
+	degree    int
+	diffusion bool
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{rand: rand.Reader, diffusion: true}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithRand sets the source of randomness used to pick the polynomial
+// coefficients. It defaults to crypto/rand.Reader.
+func WithRand(r io.Reader) Option {
+	return func(c *config) {
+		c.rand = r
+	}
+}
+
+// WithToken sets the token prefixed to the shares, like the -w flag of
+// ssss-split.
+func WithToken(token string) Option {
+	return func(c *config) {
+		c.token = token
+	}
+}
+
+// WithSecurity sets the security level in bits, like the -s flag of
+// ssss-split. It must be a multiple of 8 between 8 and 1024 and defaults to 8
+// times the length of the secret. A shorter secret is padded with leading
+// zero bytes, which ssss-combine strips when printing the secret as text.
+func WithSecurity(bits int) Option {
+	return func(c *config) {
+		c.degree = bits
+	}
+}
+
+// WithoutDiffusion disables the diffusion layer, like the -D flag of ssss.
+// Shares split without diffusion must be combined without diffusion.
+func WithoutDiffusion() Option {
+	return func(c *config) {
+		c.diffusion = false
+	}
+}
+
+// Share is a parsed ssss share.
+type Share struct {
+	Token string
+	X     int
+	Y     []byte
+}
+
+// Parse parses a share of the form `[token-]index-hexdata`.
+func Parse(s string) (*Share, error) {
+	fields := strings.Split(strings.TrimSpace(s), "-")
+	var share Share
+	switch len(fields) {
+	case 2:
+	case 3:
+		share.Token = fields[0]
+		fields = fields[1:]
+	default:
+		return nil, fmt.Errorf("invalid syntax")
+	}
+
+	x, err := strconv.Atoi(fields[0])
+	if nil != err || x < 1 {
+		return nil, fmt.Errorf("invalid share")
+	}
+	share.X = x
+
+	if _, err := newField(4 * len(fields[1])); nil != err {
+		return nil, fmt.Errorf("share has illegal length")
+	}
+	y, ok := new(big.Int).SetString(fields[1], 16)
+	if !ok || y.Sign() < 0 {
+		return nil, fmt.Errorf("invalid syntax")
+	}
+	share.Y = y.FillBytes(make([]byte, len(fields[1])/2))
+
+	return &share, nil
+}
+
+// format returns the share in the format of ssss-split, padding the index to
+// the given number of digits.
+func (s *Share) format(digits int) string {
+	prefix := ""
+	if "" != s.Token {
+		prefix = s.Token + "-"
+	}
+	return fmt.Sprintf("%s%0*d-%x", prefix, digits, s.X, s.Y)
+}
+
+// String returns the share in the format of ssss-split.
+func (s *Share) String() string {
+	return s.format(1)
+}
+
+// Split takes a secret of at most 128 bytes and generates a `parts` number of
+// shares in the format of ssss-split, `threshold` of which are required to
+// reconstruct the secret. The shares have the x coordinates 1 to parts.
+func Split(secret []byte, parts, threshold int, opts ...Option) ([]string, error) {
+	cfg := newConfig(opts)
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("cannot split an empty secret")
+	}
+	if threshold < 2 {
+		return nil, fmt.Errorf("threshold must be at least 2")
+	}
+	if parts < threshold {
+		return nil, fmt.Errorf("parts cannot be less than threshold")
+	}
+	if len(cfg.token) > maxTokenLen || strings.ContainsAny(cfg.token, "-\n") {
+		return nil, fmt.Errorf("token must be at most %d characters long and must not contain dashes", maxTokenLen)
+	}
+
+	degree := cfg.degree
+	if degree == 0 {
+		degree = 8 * len(secret)
+	}
+	f, err := newField(degree)
+	if nil != err {
+		return nil, err
+	}
+	if len(secret) > degree/8 {
+		return nil, fmt.Errorf("secret is too long for the security level")
+	}
+	if degree < 31 && parts >= 1<<uint(degree) {
+		return nil, fmt.Errorf("parts cannot exceed %d", 1<<uint(degree)-1)
+	}
+
+	b := make([]byte, degree/8)
+	copy(b[len(b)-len(secret):], secret)
+	defer zero(b)
+	if cfg.diffusion && degree >= minDiffusionDegree {
+		diffuse(b, false)
+	}
+
+	// The polynomial is monic of degree threshold, the coefficients below
+	// the leading one are the secret and random field elements.
+	coeffs := make([]*big.Int, threshold)
+	coeffs[0] = new(big.Int).SetBytes(b)
+	for i := 1; i < threshold; i++ {
+		if _, err := io.ReadFull(cfg.rand, b); nil != err {
+			return nil, fmt.Errorf("failed to generate polynomial: %v", err)
+		}
+		coeffs[i] = new(big.Int).SetBytes(b)
+	}
+
+	digits := len(strconv.Itoa(parts))
+	shares := make([]string, parts)
+	for i := range shares {
+		s := Share{Token: cfg.token, X: i + 1}
+		s.Y = horner(f, coeffs, big.NewInt(int64(s.X))).FillBytes(make([]byte, degree/8))
+		shares[i] = s.format(digits)
+	}
+
+	return shares, nil
+}
+
+// horner evaluates the monic polynomial with the given lower coefficients at
+// x.
+func horner(f *field, coeffs []*big.Int, x *big.Int) *big.Int {
+	y := new(big.Int).Set(x)
+	for i := len(coeffs) - 1; i > 0; i-- {
+		y = f.mul(f.add(y, coeffs[i]), x)
+	}
+	return f.add(y, coeffs[0])
+}
+
+// Combine reconstructs the secret from exactly `threshold` shares in the
+// format of ssss-split. Unlike shares of the parent package, ssss shares do
+// not record the threshold, so it must be known, like the -t flag of
+// ssss-combine. Surplus shares are ignored. The secret is returned with the
+// length of the security level, including the leading zero bytes a shorter
+// secret was padded with.
+func Combine(shares []string, threshold int, opts ...Option) ([]byte, error) {
+	cfg := newConfig(opts)
+	if threshold < 2 {
+		return nil, fmt.Errorf("threshold must be at least 2")
+	}
+	if len(shares) < threshold {
+		return nil, fmt.Errorf("at least %d shares are required to reconstruct the secret", threshold)
+	}
+
+	parsed := make([]*Share, threshold)
+	for i, s := range shares[:threshold] {
+		share, err := Parse(s)
+		if nil != err {
+			return nil, err
+		}
+		if i > 0 && len(share.Y) != len(parsed[0].Y) {
+			return nil, fmt.Errorf("shares have different security levels")
+		}
+		parsed[i] = share
+	}
+
+	degree := 8 * len(parsed[0].Y)
+	f, err := newField(degree)
+	if nil != err {
+		return nil, err
+	}
+
+	// Removing the leading term of the monic polynomial leaves a polynomial
+	// of degree threshold-1, whose value at 0 is found by Lagrange
+	// interpolation.
+	xs := make([]*big.Int, threshold)
+	for i, s := range parsed {
+		xs[i] = big.NewInt(int64(s.X))
+		if xs[i].BitLen() > degree {
+			return nil, fmt.Errorf("invalid share")
+		}
+		for _, x := range xs[:i] {
+			if x.Cmp(xs[i]) == 0 {
+				return nil, fmt.Errorf("shares inconsistent, perhaps a single share was used twice")
+			}
+		}
+	}
+
+	secret := new(big.Int)
+	for i, s := range parsed {
+		y := new(big.Int).SetBytes(s.Y)
+		lead := big.NewInt(1)
+		for range parsed {
+			lead = f.mul(lead, xs[i])
+		}
+		term := f.add(y, lead)
+		for j := range parsed {
+			if i != j {
+				term = f.mul(term, f.mul(xs[j], f.inv(f.add(xs[i], xs[j]))))
+			}
+		}
+		secret = f.add(secret, term)
+	}
+
+	b := secret.FillBytes(make([]byte, degree/8))
+	if cfg.diffusion && degree >= minDiffusionDegree {
+		diffuse(b, true)
+	}
+
+	return b, nil
+}
+
+// zero overwrites b with zeros.
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,143 @@
+package ssss
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// manShares are the shares of the example in the documentation of ssss,
+// splitting "my secret root password" using a (3,5) scheme.
+var manShares = []string{
+	"1-1c41ef496eccfbeba439714085df8437236298da8dd824",
+	"2-fbc74a03a50e14ab406c225afb5f45c40ae11976d2b665",
+	"3-fa1c3a9c6df8af0779c36de6c33f6e36e989d0e0b91309",
+	"4-468de7d6eb36674c9cf008c8e8fc8c566537ad6301eb9e",
+	"5-4756974923c0dce0a55f4774d09ca7a4865f64f56a4ee0",
+}
+
+func TestCombine_ssss(t *testing.T) {
+	for _, idx := range [][]int{{2, 4, 1}, {0, 1, 2}, {4, 3, 0}} {
+		shares := make([]string, 0, len(idx))
+		for _, i := range idx {
+			shares = append(shares, manShares[i])
+		}
+		out, err := Combine(shares, 3)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if string(out) != "my secret root password" {
+			t.Fatalf("bad: %q", out)
+		}
+	}
+
+	out, err := Combine(manShares[:3], 3, WithoutDiffusion())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if string(out) == "my secret root password" {
+		t.Fatalf("expect different result")
+	}
+}
+
+func TestSplit(t *testing.T) {
+	secret := []byte("my secret root password")
+
+	shares, err := Split(secret, 12, 3, WithToken("root"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(shares) != 12 {
+		t.Fatalf("bad: %v", shares)
+	}
+	if !strings.HasPrefix(shares[0], "root-01-") || !strings.HasPrefix(shares[11], "root-12-") {
+		t.Fatalf("bad: %v", shares)
+	}
+	if len(shares[0]) != len("root-01-")+2*len(secret) {
+		t.Fatalf("bad: %v", shares[0])
+	}
+
+	out, err := Combine([]string{shares[11], shares[3], shares[7]}, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(out, secret) {
+		t.Fatalf("bad: %q", out)
+	}
+}
+
+func TestSplit_options(t *testing.T) {
+	secret := []byte("short")
+
+	tests := map[string][]Option{
+		"security":     {WithSecurity(128)},
+		"no diffusion": {WithSecurity(128), WithoutDiffusion()},
+		"small":        {WithoutDiffusion()},
+	}
+	for name, opts := range tests {
+		shares, err := Split(secret, 3, 2, opts...)
+		if err != nil {
+			t.Fatalf("%s: err: %v", name, err)
+		}
+		out, err := Combine(shares[1:], 2, opts...)
+		if err != nil {
+			t.Fatalf("%s: err: %v", name, err)
+		}
+		if !bytes.Equal(bytes.TrimLeft(out, "\x00"), secret) {
+			t.Fatalf("%s: bad: %q", name, out)
+		}
+	}
+}
+
+func TestSplit_invalid(t *testing.T) {
+	tests := map[string]struct {
+		secret           []byte
+		parts, threshold int
+		opts             []Option
+	}{
+		"empty":     {nil, 3, 2, nil},
+		"threshold": {[]byte("test"), 3, 4, nil},
+		"minimum":   {[]byte("test"), 3, 1, nil},
+		"security":  {[]byte("test"), 3, 2, []Option{WithSecurity(12)}},
+		"too long":  {[]byte("test"), 3, 2, []Option{WithSecurity(16)}},
+		"parts":     {[]byte("t"), 256, 2, nil},
+		"token":     {[]byte("test"), 3, 2, []Option{WithToken("a-b")}},
+		"secret":    {make([]byte, 129), 3, 2, nil},
+	}
+	for name, test := range tests {
+		if _, err := Split(test.secret, test.parts, test.threshold, test.opts...); err == nil {
+			t.Errorf("%s: expect error", name)
+		}
+	}
+}
+
+func TestCombine_invalid(t *testing.T) {
+	tests := map[string][]string{
+		"syntax":    {"1", "2-00"},
+		"index":     {"0-00", "2-00"},
+		"hex":       {"1-zz", "2-00"},
+		"length":    {"1-00", "2-0000"},
+		"illegal":   {"1-000", "2-000"},
+		"duplicate": {"1-00", "01-00"},
+		"too few":   {"1-00"},
+		"x":         {"1-00", "256-00"},
+	}
+	for name, shares := range tests {
+		if _, err := Combine(shares, 2); err == nil {
+			t.Errorf("%s: expect error", name)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	s, err := Parse("foo-03-0a0b\n")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if s.Token != "foo" || s.X != 3 || !bytes.Equal(s.Y, []byte{10, 11}) {
+		t.Fatalf("bad: %v", s)
+	}
+	if s.String() != "foo-3-0a0b" {
+		t.Fatalf("bad: %s", s)
+	}
+}
@@ -0,0 +1,147 @@
+//go:build vault
+
+package compat
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"testing"
+
+	"github.com/corvus-ch/shamir/vault"
+	vaultshamir "github.com/hashicorp/vault/shamir"
+)
+
+const vectorsFile = "../testdata/vectors.json"
+
+var update = flag.Bool("update", false, "regenerate "+vectorsFile+" using Vault")
+
+type vector struct {
+	Secret    string   `json:"secret"`
+	Threshold int      `json:"threshold"`
+	Parts     []string `json:"parts"`
+}
+
+type params struct {
+	size, parts, threshold int
+}
+
+var tests = []params{
+	{4, 3, 2},
+	{32, 5, 3},
+	{32, 7, 4},
+	{20, 10, 10},
+	{1, 255, 2},
+}
+
+func random(t *testing.T, n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return b
+}
+
+func decodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return b
+}
+
+// generate splits secrets using Vault.
+func generate(t *testing.T) []vector {
+	var out []vector
+	for i, p := range tests {
+		secret := []byte("test")
+		if i > 0 {
+			secret = random(t, p.size)
+		}
+		parts, err := vaultshamir.Split(secret, p.parts, p.threshold)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		v := vector{Secret: hex.EncodeToString(secret), Threshold: p.threshold}
+		for _, part := range parts {
+			v.Parts = append(v.Parts, hex.EncodeToString(part))
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+func TestVectors(t *testing.T) {
+	if *update {
+		data, err := json.MarshalIndent(generate(t), "", "  ")
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if err := ioutil.WriteFile(vectorsFile, append(data, '\n'), 0644); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	data, err := ioutil.ReadFile(vectorsFile)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var vectors []vector
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(vectors) != len(tests) {
+		t.Fatalf("bad: %d vectors", len(vectors))
+	}
+
+	// Vault accepts the vendored vectors.
+	for i, v := range vectors {
+		parts := make([][]byte, 0, len(v.Parts))
+		for _, p := range v.Parts[len(v.Parts)-v.Threshold:] {
+			parts = append(parts, decodeHex(t, p))
+		}
+		recomb, err := vaultshamir.Combine(parts)
+		if err != nil {
+			t.Fatalf("%d: err: %v", i, err)
+		}
+		if !bytes.Equal(recomb, decodeHex(t, v.Secret)) {
+			t.Fatalf("%d: bad: %x", i, recomb)
+		}
+	}
+}
+
+func TestCombine(t *testing.T) {
+	for i, v := range generate(t) {
+		parts := make([][]byte, 0, v.Threshold)
+		for _, p := range v.Parts[:v.Threshold] {
+			parts = append(parts, decodeHex(t, p))
+		}
+		recomb, err := vault.Combine(parts)
+		if err != nil {
+			t.Fatalf("%d: err: %v", i, err)
+		}
+		if !bytes.Equal(recomb, decodeHex(t, v.Secret)) {
+			t.Fatalf("%d: bad: %x", i, recomb)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	for i, p := range tests {
+		secret := random(t, p.size)
+		parts, err := vault.Split(secret, p.parts, p.threshold)
+		if err != nil {
+			t.Fatalf("%d: err: %v", i, err)
+		}
+		recomb, err := vaultshamir.Combine(parts[len(parts)-p.threshold:])
+		if err != nil {
+			t.Fatalf("%d: err: %v", i, err)
+		}
+		if !bytes.Equal(recomb, secret) {
+			t.Fatalf("%d: bad: %x", i, recomb)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+// Package compat checks that the vault package is compatible with
+// github.com/hashicorp/vault/shamir and regenerates the test vectors of the
+// vault package.
+//
+// As Vault pulls in a large number of dependencies, this is a separate module
+// and its tests only run with the vault build tag:
+//
+//	cd vault/compat
+//	go test -tags vault ./...
+//
+// To regenerate ../testdata/vectors.json using Vault, run:
+//
+//	go test -tags vault -run TestVectors -update
+package compat
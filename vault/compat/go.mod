@@ -0,0 +1,10 @@
+module github.com/corvus-ch/shamir/vault/compat
+
+go 1.25.3
+
+require (
+	github.com/corvus-ch/shamir v0.0.0
+	github.com/hashicorp/vault v1.21.4
+)
+
+replace github.com/corvus-ch/shamir => ../..
@@ -0,0 +1,101 @@
+// Package vault splits and combines secrets in the format used by
+// github.com/hashicorp/vault/shamir, such as Vault's unseal keys.
+//
+// Vault computes the shares in GF(2^8) with the AES reduction polynomial 0x11b
+// and appends the x coordinate to the y values of each share as a trailing
+// tag byte. Shares created by this package can be combined by Vault and vice
+// versa.
+package vault
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/corvus-ch/shamir"
+)
+
+// Split takes an arbitrarily long secret and generates a `parts` number of
+// shares, `threshold` of which are required to reconstruct the secret. The
+// returned shares are each one byte longer than the secret as they attach the
+// x coordinate as tag. The options are passed on to shamir.Split, the field
+// is always shamir.AESField.
+func Split(secret []byte, parts, threshold int, opts ...shamir.Option) ([][]byte, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("cannot split an empty secret")
+	}
+	if threshold > 255 {
+		return nil, fmt.Errorf("threshold cannot exceed 255")
+	}
+
+	opts = append(opts[:len(opts):len(opts)], shamir.WithField(shamir.AESField))
+	out, err := shamir.Split(secret, parts, threshold, opts...)
+	if nil != err {
+		return nil, err
+	}
+
+	xs := make([]byte, 0, len(out))
+	for x := range out {
+		xs = append(xs, x)
+	}
+	sort.Slice(xs, func(i, j int) bool { return xs[i] < xs[j] })
+
+	result := make([][]byte, 0, len(xs))
+	for _, x := range xs {
+		result = append(result, Format(x, out[x]))
+	}
+
+	return result, nil
+}
+
+// Combine is used to reverse a Split and reconstruct a secret once a
+// `threshold` number of parts are available.
+func Combine(parts [][]byte) ([]byte, error) {
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("less than two parts cannot be used to reconstruct the secret")
+	}
+
+	firstPartLen := len(parts[0])
+	if firstPartLen < 2 {
+		return nil, fmt.Errorf("parts must be at least two bytes")
+	}
+
+	m := make(map[byte][]byte, len(parts))
+	for _, part := range parts {
+		if len(part) != firstPartLen {
+			return nil, fmt.Errorf("all parts must be the same length")
+		}
+		x, y, err := Parse(part)
+		if nil != err {
+			return nil, err
+		}
+		if _, ok := m[x]; ok {
+			return nil, fmt.Errorf("duplicate part detected")
+		}
+		m[x] = y
+	}
+
+	return shamir.Combine(m, shamir.WithField(shamir.AESField))
+}
+
+// Format returns the share with x coordinate x and y values y in the format of
+// Vault. Together with Parse, it converts between the parts of shamir.Split
+// and shamir.Combine using shamir.AESField and the parts used by Vault.
+func Format(x byte, y []byte) []byte {
+	part := make([]byte, len(y)+1)
+	copy(part, y)
+	part[len(y)] = x
+	return part
+}
+
+// Parse splits a share in the format of Vault into its x coordinate and its y
+// values. The y values share the memory of part.
+func Parse(part []byte) (byte, []byte, error) {
+	if len(part) < 2 {
+		return 0, nil, fmt.Errorf("parts must be at least two bytes")
+	}
+	x := part[len(part)-1]
+	if x == 0 {
+		return 0, nil, fmt.Errorf("x coordinate cannot be zero")
+	}
+	return x, part[:len(part)-1], nil
+}
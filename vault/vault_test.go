@@ -0,0 +1,168 @@
+package vault
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/corvus-ch/shamir"
+)
+
+type vector struct {
+	Secret    string   `json:"secret"`
+	Threshold int      `json:"threshold"`
+	Parts     []string `json:"parts"`
+}
+
+func loadVectors(t *testing.T) []vector {
+	data, err := ioutil.ReadFile("testdata/vectors.json")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var vectors []vector
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return vectors
+}
+
+func decodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return b
+}
+
+func TestCombine_vectors(t *testing.T) {
+	for i, v := range loadVectors(t) {
+		secret := decodeHex(t, v.Secret)
+		parts := make([][]byte, len(v.Parts))
+		for j, p := range v.Parts {
+			parts[j] = decodeHex(t, p)
+		}
+
+		// Every window of threshold consecutive parts reconstructs the secret.
+		for j := 0; j+v.Threshold <= len(parts); j++ {
+			out, err := Combine(parts[j : j+v.Threshold])
+			if err != nil {
+				t.Fatalf("%d: err: %v", i, err)
+			}
+			if !bytes.Equal(out, secret) {
+				t.Fatalf("%d: bad: %x %x", i, out, secret)
+			}
+		}
+
+		if v.Threshold > 2 {
+			out, err := Combine(parts[:v.Threshold-1])
+			if err != nil {
+				t.Fatalf("%d: err: %v", i, err)
+			}
+			if bytes.Equal(out, secret) {
+				t.Fatalf("%d: expect different result", i)
+			}
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	secret := []byte("test")
+
+	parts, err := Split(secret, 5, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(parts) != 5 {
+		t.Fatalf("bad: %v", parts)
+	}
+
+	for i, part := range parts {
+		if len(part) != len(secret)+1 {
+			t.Fatalf("bad: %v", part)
+		}
+		if i > 0 && part[len(part)-1] <= parts[i-1][len(part)-1] {
+			t.Fatalf("parts not ordered: %v", parts)
+		}
+	}
+
+	out, err := Combine(parts[1:4])
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(out, secret) {
+		t.Fatalf("bad: %v %v", out, secret)
+	}
+}
+
+func TestSplit_options(t *testing.T) {
+	parts, err := Split([]byte("test"), 3, 2, shamir.WithCoordinates(7, 8, 9), shamir.WithField(shamir.DefaultField))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	x, y, err := Parse(parts[0])
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if x != 7 {
+		t.Fatalf("bad: %v", x)
+	}
+
+	// The field of Vault cannot be overridden.
+	out, err := shamir.Combine(map[byte][]byte{x: y, 9: parts[2][:4]}, shamir.WithField(shamir.AESField))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if string(out) != "test" {
+		t.Fatalf("bad: %q", out)
+	}
+}
+
+func TestSplit_invalid(t *testing.T) {
+	tests := map[string]struct {
+		secret           []byte
+		parts, threshold int
+	}{
+		"empty":     {nil, 3, 2},
+		"threshold": {[]byte("test"), 3, 4},
+		"parts":     {[]byte("test"), 256, 2},
+		"minimum":   {[]byte("test"), 3, 1},
+	}
+	for name, test := range tests {
+		if _, err := Split(test.secret, test.parts, test.threshold); err == nil {
+			t.Errorf("%s: expect error", name)
+		}
+	}
+}
+
+func TestCombine_invalid(t *testing.T) {
+	tests := map[string][][]byte{
+		"one part":  {{1, 1}},
+		"short":     {{1}, {2}},
+		"length":    {{1, 1}, {2, 2, 2}},
+		"duplicate": {{1, 1}, {2, 1}},
+		"zero x":    {{1, 0}, {2, 1}},
+	}
+	for name, parts := range tests {
+		if _, err := Combine(parts); err == nil {
+			t.Errorf("%s: expect error", name)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	part := Format(42, []byte("foo"))
+	if !bytes.Equal(part, []byte("foo*")) {
+		t.Fatalf("bad: %q", part)
+	}
+
+	x, y, err := Parse(part)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if x != 42 || string(y) != "foo" {
+		t.Fatalf("bad: %v %q", x, y)
+	}
+}
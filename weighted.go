@@ -0,0 +1,140 @@
+package shamir
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Bundle holds all shares of a holder, one for each unit of its weight. Each
+// share counts as one towards the threshold.
+//
+// The binary encoding of a bundle consists of the following fields:
+//
+//	magic      4 bytes  "GFSB"
+//	version    1 byte   format version, currently 1
+//	count      1 byte   number of shares
+//
+// followed by each share as a 4 byte big endian length and the binary encoding
+// of the share.
+type Bundle struct {
+	Shares []*Share
+}
+
+const (
+	bundleMagic   = "GFSB"
+	bundleVersion = 1
+)
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (b *Bundle) MarshalBinary() ([]byte, error) {
+	if len(b.Shares) < 1 || len(b.Shares) > 255 {
+		return nil, fmt.Errorf("bundle must hold between 1 and 255 shares")
+	}
+
+	out := append([]byte(bundleMagic), bundleVersion, byte(len(b.Shares)))
+	for _, s := range b.Shares {
+		data, err := s.MarshalBinary()
+		if nil != err {
+			return nil, err
+		}
+		var n [4]byte
+		binary.BigEndian.PutUint32(n[:], uint32(len(data)))
+		out = append(append(out, n[:]...), data...)
+	}
+	return out, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (b *Bundle) UnmarshalBinary(data []byte) error {
+	if len(data) < 6 {
+		return fmt.Errorf("bundle is too short")
+	}
+	if string(data[:4]) != bundleMagic {
+		return fmt.Errorf("not a bundle")
+	}
+	if data[4] != bundleVersion {
+		return fmt.Errorf("unsupported bundle version %d", data[4])
+	}
+
+	if data[5] == 0 {
+		return fmt.Errorf("bundle must hold at least one share")
+	}
+	shares := make([]*Share, data[5])
+	data = data[6:]
+	for i := range shares {
+		if len(data) < 4 {
+			return fmt.Errorf("bundle is too short")
+		}
+		n := binary.BigEndian.Uint32(data)
+		data = data[4:]
+		if uint64(len(data)) < uint64(n) {
+			return fmt.Errorf("bundle is too short")
+		}
+		shares[i] = &Share{}
+		if err := shares[i].UnmarshalBinary(data[:n]); nil != err {
+			return fmt.Errorf("share %d: %v", i, err)
+		}
+		data = data[n:]
+	}
+	if len(data) != 0 {
+		return fmt.Errorf("trailing data after bundle")
+	}
+
+	b.Shares = shares
+	return nil
+}
+
+// SplitWeighted works like SplitShares but for holders of different weight.
+// Each holder receives a bundle of as many shares as its weight and the
+// threshold is the sum of weights required to reconstruct the secret. With
+// weights of 2, 1 and 1 and a threshold of 3 for example, the first holder
+// and any other one can reconstruct the secret, but not the other two on
+// their own.
+//
+// As each share uses a distinct x coordinate, the sum of the weights must not
+// exceed 255. The bundles are returned in the order of the weights.
+func SplitWeighted(secret []byte, weights []int, threshold int, opts ...Option) ([]*Bundle, error) {
+	parts := 0
+	for _, w := range weights {
+		if w < 1 {
+			return nil, fmt.Errorf("weights must be at least 1")
+		}
+		parts += w
+		if parts > 255 {
+			return nil, fmt.Errorf("sum of weights cannot exceed 255")
+		}
+	}
+
+	shares, err := SplitShares(secret, parts, threshold, opts...)
+	if nil != err {
+		return nil, err
+	}
+
+	// Hand out the shares in the order of the x coordinates given, if any.
+	if xs := newConfig(opts).xs; nil != xs {
+		byX := make(map[byte]*Share, len(shares))
+		for _, s := range shares {
+			byX[s.X] = s
+		}
+		for i, x := range xs {
+			shares[i] = byX[x]
+		}
+	}
+
+	bundles := make([]*Bundle, len(weights))
+	for i, w := range weights {
+		bundles[i] = &Bundle{Shares: shares[:w:w]}
+		shares = shares[w:]
+	}
+	return bundles, nil
+}
+
+// CombineBundles works like CombineShares but takes bundles as returned by
+// SplitWeighted.
+func CombineBundles(bundles ...*Bundle) ([]byte, error) {
+	var shares []*Share
+	for _, b := range bundles {
+		shares = append(shares, b.Shares...)
+	}
+	return CombineShares(shares)
+}
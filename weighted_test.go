@@ -0,0 +1,111 @@
+package shamir
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSplitWeighted(t *testing.T) {
+	secret := []byte("test")
+
+	bundles, err := SplitWeighted(secret, []int{2, 1, 1}, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(bundles) != 3 || len(bundles[0].Shares) != 2 || len(bundles[1].Shares) != 1 || len(bundles[2].Shares) != 1 {
+		t.Fatalf("bad: %v", bundles)
+	}
+
+	for _, set := range [][]*Bundle{
+		{bundles[0], bundles[1]},
+		{bundles[2], bundles[0]},
+		bundles,
+	} {
+		recomb, err := CombineBundles(set...)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !bytes.Equal(recomb, secret) {
+			t.Fatalf("bad: %v %v", recomb, secret)
+		}
+	}
+
+	if _, err := CombineBundles(bundles[1], bundles[2]); err != ErrNotEnoughShares {
+		t.Fatalf("bad: %v", err)
+	}
+	if _, err := CombineBundles(bundles[0]); err != ErrNotEnoughShares {
+		t.Fatalf("bad: %v", err)
+	}
+}
+
+func TestSplitWeighted_coordinates(t *testing.T) {
+	bundles, err := SplitWeighted([]byte("test"), []int{1, 2}, 2, WithCoordinates(9, 3, 5))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if bundles[0].Shares[0].X != 9 || bundles[1].Shares[0].X != 3 || bundles[1].Shares[1].X != 5 {
+		t.Fatalf("bad: %v", bundles)
+	}
+}
+
+func TestSplitWeighted_invalid(t *testing.T) {
+	secret := []byte("test")
+
+	for _, weights := range [][]int{{1, 0}, {1, -1}, {200, 56}} {
+		if _, err := SplitWeighted(secret, weights, 2); err == nil {
+			t.Errorf("%v: expect error", weights)
+		}
+	}
+	if _, err := SplitWeighted(secret, []int{1, 1}, 3); err == nil {
+		t.Fatalf("expect error")
+	}
+	if _, err := SplitWeighted(secret, []int{200, 55}, 2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestBundle_MarshalBinary(t *testing.T) {
+	secret := []byte("test")
+
+	bundles, err := SplitWeighted(secret, []int{3, 1}, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	data, err := bundles[0].MarshalBinary()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if string(data[:6]) != "GFSB\x01\x03" {
+		t.Fatalf("bad: %q", data[:6])
+	}
+
+	var b Bundle
+	if err := b.UnmarshalBinary(data); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	recomb, err := CombineBundles(&b)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(recomb, secret) {
+		t.Fatalf("bad: %v %v", recomb, secret)
+	}
+
+	for _, in := range [][]byte{
+		nil,
+		[]byte("GFSS\x01\x01"),
+		[]byte("GFSB\x02\x01"),
+		[]byte("GFSB\x01\x00"),
+		data[:len(data)-1],
+		append(data[:len(data):len(data)], 0),
+		[]byte("GFSB\x01\x01\x00\x00\x00\x01x"),
+	} {
+		if err := b.UnmarshalBinary(in); err == nil {
+			t.Errorf("%q: expect error", in)
+		}
+	}
+
+	if _, err := (&Bundle{}).MarshalBinary(); err == nil {
+		t.Fatalf("expect error")
+	}
+}